@@ -3,8 +3,10 @@ package main
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 
@@ -17,13 +19,232 @@ import (
 
 type DownloadOpts struct {
 	outputDir string
+	rootDir   string // 批量下载的根目录，image_layout 为 shared 时图片保存在其中
 	dump      bool
 	batch     bool
 	wiki      bool
+	preset    string
+	format    string
+	strict    bool   // 有内容因不支持而被丢弃或未能完整导出时返回错误
+	fileName  string // 覆盖输出文件名，用于预设的目录文档
+	position  int    // 在知识库同级节点中的位置
 }
 
 var dlOpts = DownloadOpts{}
 var dlConfig core.Config
+var dlPreset *core.Preset
+var dlDropped droppedBlocks
+
+// droppedBlocks 汇总各文档中因不支持而被丢弃或未能完整导出的 Block，在下载或同步结束时输出
+type droppedBlocks struct {
+	mu          sync.Mutex
+	documents   []string
+	diagnostics map[string][]*core.Diagnostic
+}
+
+func (d *droppedBlocks) add(path string, diagnostics []*core.Diagnostic) {
+	if len(diagnostics) == 0 {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.diagnostics == nil {
+		d.diagnostics = make(map[string][]*core.Diagnostic)
+	}
+	d.documents = append(d.documents, path)
+	d.diagnostics[path] = diagnostics
+}
+
+// count 返回被丢弃或未能完整导出的 Block 数量和涉及的文档数量
+func (d *droppedBlocks) count() (int, int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	blocks := 0
+	for _, diagnostics := range d.diagnostics {
+		blocks += len(diagnostics)
+	}
+	return blocks, len(d.documents)
+}
+
+// print 按文档列出被丢弃或未能完整导出的 Block
+func (d *droppedBlocks) print(w io.Writer) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	documents := append([]string(nil), d.documents...)
+	sort.Strings(documents)
+	for _, path := range documents {
+		fmt.Fprintf(w, "  %s\n", path)
+		for _, diagnostic := range d.diagnostics[path] {
+			fmt.Fprintf(w, "    - %s\n", diagnostic)
+		}
+	}
+}
+
+// applyPreset 将命令行或配置文件中指定的预设应用到输出配置，未指定时返回 nil
+func applyPreset(output *core.OutputConfig, name string) (*core.Preset, error) {
+	if name == "" {
+		name = output.Preset
+	}
+	if name == "" {
+		return nil, nil
+	}
+	preset, err := core.GetPreset(name)
+	if err != nil {
+		return nil, err
+	}
+	preset.Apply(output)
+	return preset, nil
+}
+
+// assetDownloader 在解析文档时下载其中的图片、画板和附件，返回相对 Markdown 文件的链接
+type assetDownloader struct {
+	ctx        context.Context
+	client     *core.Client
+	output     core.OutputConfig
+	format     string
+	documentID string
+	outputDir  string                          // 附件目录所在的目录
+	imageDir   string                          // 图片保存的目录，由 image_layout 决定
+	docDir     string                          // Markdown 文件所在的目录
+	warn       func(blockID string, err error) // 画板导出失败时调用，不中断下载
+	cache      *core.CacheManager              // 同步时通过缓存清单复用已下载的图片
+	images     []string                        // 保存的图片文件
+	links      map[string]string
+	err        error
+}
+
+// resolve 实现 core.AssetResolver，同一资源只下载一次，出错时保留 token 并记录第一个错误
+func (d *assetDownloader) resolve(asset *core.Asset) string {
+	key := asset.Kind + "/" + asset.Token
+	if link, ok := d.links[key]; ok {
+		return link
+	}
+	link, err := d.download(asset)
+	if err != nil {
+		if d.err == nil {
+			d.err = err
+		}
+		return asset.Token
+	}
+	if d.links == nil {
+		d.links = map[string]string{}
+	}
+	d.links[key] = link
+	return link
+}
+
+func (d *assetDownloader) download(asset *core.Asset) (string, error) {
+	var localPath string
+	var err error
+	switch asset.Kind {
+	case core.AssetImage, core.AssetBoard:
+		if d.output.SkipImgDownload {
+			return asset.Token, nil
+		}
+		if asset.Kind == core.AssetImage {
+			localPath, err = d.storeImage(asset.Token)
+			break
+		}
+		localPath, err = d.client.DownloadBoardImage(d.ctx, d.documentID, asset.Token, d.imageDir)
+		if err != nil {
+			// 旧版流程图/UML 等无法导出为图片，仅提示而不中断下载，文档中改为输出指向原文档的链接
+			d.warn(asset.Token, err)
+			return "", nil
+		}
+	case core.AssetFile:
+		localPath, err = d.client.DownloadAttachment(d.ctx, asset.Token, filepath.Join(d.outputDir, d.output.AttachmentDir))
+	default:
+		return asset.Token, nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// html_images 为 data_uri 时读取已下载的图片内嵌到页面中
+	if d.format == core.FormatHTML && asset.Kind != core.AssetFile && d.output.HTMLImages == core.HTMLImagesDataURI {
+		data, err := os.ReadFile(localPath)
+		if err != nil {
+			return "", err
+		}
+		return core.DataURI(localPath, data), nil
+	}
+	relPath, err := filepath.Rel(d.docDir, localPath)
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(relPath), nil
+}
+
+// storeImage 按 image_naming 保存图片，设置了缓存时已下载过的图片不再重复下载
+func (d *assetDownloader) storeImage(token string) (string, error) {
+	fetch := func() (string, []byte, error) {
+		return d.client.DownloadImageRaw(d.ctx, token, d.imageDir)
+	}
+	var localPath string
+	var err error
+	if d.cache != nil {
+		localPath, err = d.cache.StoreImage(token, d.imageDir, d.output.ImageNaming, fetch)
+	} else {
+		localPath, _, err = core.SaveImage(token, d.imageDir, d.output.ImageNaming, fetch)
+	}
+	if err != nil {
+		return "", err
+	}
+	d.images = append(d.images, localPath)
+	return localPath, nil
+}
+
+// renderHTMLPage 使用配置中的模板文件包裹 HTML 正文，未配置时使用内置模板
+func renderHTMLPage(output core.OutputConfig, title, body string) (string, error) {
+	tmpl := ""
+	if output.HTMLTemplate != "" {
+		data, err := os.ReadFile(output.HTMLTemplate)
+		if err != nil {
+			return "", err
+		}
+		tmpl = string(data)
+	}
+	return core.RenderHTMLPage(tmpl, title, body)
+}
+
+// navPath 返回 path 相对知识库根目录的斜杠路径，用于生成导航
+func navPath(root, path string) string {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// renderFrontMatter 生成写入 Markdown 头部的 front matter，元数据获取失败时只写入已知的字段
+func renderFrontMatter(ctx context.Context, client *core.Client, resolver core.UserResolver,
+	output core.OutputConfig, docx *lark.DocxDocument, url string, position int,
+) string {
+	if output.FrontMatter == "" || output.FrontMatter == core.FrontMatterNone {
+		return ""
+	}
+	info := &core.DocumentInfo{
+		Title:      docx.Title,
+		Token:      docx.DocumentID,
+		RevisionID: docx.RevisionID,
+		SourceURL:  url,
+		Position:   position,
+	}
+	meta, err := client.GetDocumentMeta(ctx, docx.DocumentID, "docx")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to get meta of %s: %v\n", docx.DocumentID, err)
+	} else {
+		info.CreatedAt = meta.CreatedAt
+		info.UpdatedAt = meta.UpdatedAt
+		info.Owner = meta.OwnerID
+		if users, err := resolver.BatchGetUsers(ctx, []string{meta.OwnerID}); err == nil {
+			if owner, ok := users[meta.OwnerID]; ok && owner.Name != "" {
+				info.Owner = owner.Name
+			}
+		}
+	}
+	return core.RenderFrontMatter(output.FrontMatter, core.BuildFrontMatterFields(output, info))
+}
 
 func downloadDocument(ctx context.Context, client *core.Client, url string, opts *DownloadOpts) error {
 	// Validate the url to download
@@ -50,7 +271,7 @@ func downloadDocument(ctx context.Context, client *core.Client, url string, opts
 	}
 
 	// Process the download
-	docx, blocks, err := client.GetDocxContent(ctx, docToken)
+	docx, blocks, properties, err := client.GetDocxContent(ctx, docToken)
 	utils.CheckErr(err)
 
 	title := docx.Title
@@ -62,30 +283,79 @@ func downloadDocument(ctx context.Context, client *core.Client, url string, opts
 	} else {
 		mdName = fmt.Sprintf("%s.md", docToken)
 	}
+	if opts.fileName != "" {
+		mdName = opts.fileName
+	}
+	if opts.format == core.FormatHTML {
+		mdName = strings.TrimSuffix(mdName, ".md") + ".html"
+	}
 	outputPath := filepath.Join(opts.outputDir, mdName)
 
 	// 继续执行下载流程
 	parser := core.NewParser(dlConfig.Output)
+	parser.SetBlockProperties(properties)
+	parser.SetEmbedFetcher(ctx, client)
+	parser.SetUserResolver(client)
+	parser.SetBaseURL(utils.ExtractBaseURL(url))
 
-	markdown := parser.ParseDocxContent(docx, blocks)
-
-	if !dlConfig.Output.SkipImgDownload {
-		for _, imgToken := range parser.ImgTokens {
-			localLink, err := client.DownloadImage(
-				ctx, imgToken, filepath.Join(opts.outputDir, dlConfig.Output.ImageDir),
-			)
-			if err != nil {
-				return err
-			}
-			markdown = strings.Replace(markdown, imgToken, localLink, 1)
+	// Export the document AST instead of markdown
+	if opts.format == core.FormatJSON {
+		if err := os.MkdirAll(opts.outputDir, 0o755); err != nil {
+			return err
+		}
+		astPath := strings.TrimSuffix(outputPath, ".md") + ".ast.json"
+		document := parser.BuildDocument(docx, blocks)
+		if err = os.WriteFile(astPath, []byte(utils.PrettyPrint(document)), 0o644); err != nil {
+			return err
 		}
+		fmt.Printf("✓ Exported document AST to %s\n", astPath)
+		dlDropped.add(astPath, parser.Diagnostics)
+		return nil
+	}
+
+	// Download images and attachments while parsing
+	rootDir := opts.rootDir
+	if rootDir == "" {
+		rootDir = opts.outputDir
+	}
+	assets := &assetDownloader{
+		ctx:        ctx,
+		client:     client,
+		output:     dlConfig.Output,
+		format:     opts.format,
+		documentID: docx.DocumentID,
+		outputDir:  opts.outputDir,
+		imageDir:   dlConfig.Output.DocImageDir(rootDir, outputPath),
+		docDir:     filepath.Dir(outputPath),
+		warn: func(blockID string, err error) {
+			fmt.Fprintf(os.Stderr, "Warning: failed to export board %s: %v\n", blockID, err)
+		},
+	}
+	parser.SetAssetResolver(assets.resolve)
+
+	var content string
+	if opts.format == core.FormatHTML {
+		content = core.NewHTMLEmitter(dlConfig.Output).Emit(parser.BuildDocument(docx, blocks))
+	} else {
+		content = parser.ParseDocxContent(docx, blocks)
+	}
+	if assets.err != nil {
+		return assets.err
 	}
 
-	// Format the markdown document
-	engine := lute.New(func(l *lute.Lute) {
-		l.RenderOptions.AutoSpace = true
-	})
-	result := engine.FormatStr("md", markdown)
+	// Format the output document
+	var result string
+	if opts.format == core.FormatHTML {
+		if result, err = renderHTMLPage(dlConfig.Output, title, content); err != nil {
+			return err
+		}
+	} else {
+		engine := lute.New(func(l *lute.Lute) {
+			l.RenderOptions.AutoSpace = true
+		})
+		result = engine.FormatStr("md", content)
+		result = renderFrontMatter(ctx, client, client, dlConfig.Output, docx, url, opts.position) + result
+	}
 
 	// Handle the output directory and name
 	if _, err := os.Stat(opts.outputDir); os.IsNotExist(err) {
@@ -98,11 +368,13 @@ func downloadDocument(ctx context.Context, client *core.Client, url string, opts
 		jsonName := fmt.Sprintf("%s.json", docToken)
 		jsonOutputPath := filepath.Join(opts.outputDir, jsonName)
 		data := struct {
-			Document *lark.DocxDocument `json:"document"`
-			Blocks   []*lark.DocxBlock  `json:"blocks"`
+			Document   *lark.DocxDocument       `json:"document"`
+			Blocks     []*lark.DocxBlock        `json:"blocks"`
+			Properties core.DocxBlockProperties `json:"properties"`
 		}{
-			Document: docx,
-			Blocks:   blocks,
+			Document:   docx,
+			Blocks:     blocks,
+			Properties: properties,
 		}
 		pdata := utils.PrettyPrint(data)
 
@@ -112,11 +384,20 @@ func downloadDocument(ctx context.Context, client *core.Client, url string, opts
 		fmt.Printf("Dumped json response to %s\n", jsonOutputPath)
 	}
 
-	// Write to markdown file
+	// Write the sidecar files next to the markdown file
+	for _, sidecar := range parser.Sidecars {
+		sidecarPath := filepath.Join(opts.outputDir, sidecar.Name)
+		if err = os.WriteFile(sidecarPath, sidecar.Content, 0o644); err != nil {
+			return err
+		}
+	}
+
+	// Write to output file
 	if err = os.WriteFile(outputPath, []byte(result), 0o644); err != nil {
 		return err
 	}
-	fmt.Printf("✓ Downloaded markdown file to %s\n", outputPath)
+	fmt.Printf("✓ Downloaded %s file to %s\n", opts.format, outputPath)
+	dlDropped.add(outputPath, parser.Diagnostics)
 
 	return nil
 }
@@ -142,8 +423,10 @@ func downloadDocuments(ctx context.Context, client *core.Client, url string) err
 		}
 		opts := DownloadOpts{
 			outputDir: folderPath,
+			rootDir:   dlOpts.outputDir,
 			dump:      dlOpts.dump,
 			batch:     false,
+			format:    dlOpts.format,
 		}
 		for _, file := range files {
 			if file.Type == "folder" {
@@ -203,30 +486,48 @@ func downloadWiki(ctx context.Context, client *core.Client, url string) error {
 		client *core.Client,
 		spaceID string,
 		parentPath string,
-		parentNodeToken *string) error
+		parentNodeToken *string) ([]*core.NavNode, error)
 
+	rootPath := folderPath
 	downloadWikiNode = func(ctx context.Context,
 		client *core.Client,
 		spaceID string,
 		folderPath string,
-		parentNodeToken *string) error {
+		parentNodeToken *string) ([]*core.NavNode, error) {
 		nodes, err := client.GetWikiNodeList(ctx, spaceID, parentNodeToken)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		for _, n := range nodes {
+		var nav []*core.NavNode
+		for i, n := range nodes {
+			navNode := &core.NavNode{Title: n.Title, Position: i + 1}
+			docPath := folderPath
 			if n.HasChild {
 				_folderPath := filepath.Join(folderPath, n.Title)
-				if err := downloadWikiNode(ctx, client,
-					spaceID, _folderPath, &n.NodeToken); err != nil {
-					return err
+				children, err := downloadWikiNode(ctx, client,
+					spaceID, _folderPath, &n.NodeToken)
+				if err != nil {
+					return nil, err
+				}
+				navNode.Dir = navPath(rootPath, _folderPath)
+				navNode.Children = children
+				// 预设要求目录文档保存在子目录中，如 index 文件或 folder note
+				if dlPreset != nil && dlPreset.IndexInFolder() {
+					docPath = _folderPath
 				}
 			}
 			if n.ObjType == "docx" {
 				opts := DownloadOpts{
-					outputDir: folderPath,
+					outputDir: docPath,
+					rootDir:   rootPath,
 					dump:      dlOpts.dump,
 					batch:     false,
+					format:    dlOpts.format,
+					position:  i + 1,
+				}
+				if dlPreset != nil {
+					opts.fileName = dlPreset.DocFileName(n.Title, n.HasChild)
+					navNode.Path = navPath(rootPath, filepath.Join(docPath, opts.fileName))
 				}
 				wg.Add(1)
 				semaphore <- struct{}{}
@@ -238,11 +539,15 @@ func downloadWiki(ctx context.Context, client *core.Client, url string) error {
 					<-semaphore
 				}(prefixURL + "/wiki/" + n.NodeToken)
 			}
+			if navNode.Path != "" || len(navNode.Children) > 0 {
+				nav = append(nav, navNode)
+			}
 		}
-		return nil
+		return nav, nil
 	}
 
-	if err = downloadWikiNode(ctx, client, spaceID, folderPath, nil); err != nil {
+	nav, err := downloadWikiNode(ctx, client, spaceID, folderPath, nil)
+	if err != nil {
 		return err
 	}
 
@@ -254,6 +559,14 @@ func downloadWiki(ctx context.Context, client *core.Client, url string) error {
 	for err := range errChan {
 		return err
 	}
+
+	// Generate the navigation file of the static site generator
+	if dlPreset != nil && dlPreset.WriteNav != nil {
+		if err := dlPreset.WriteNav(folderPath, nav); err != nil {
+			return err
+		}
+		fmt.Printf("✓ Generated %s navigation for %s\n", dlPreset.Name, folderPath)
+	}
 	return nil
 }
 
@@ -268,6 +581,13 @@ func handleDownloadCommand(url string) error {
 		return err
 	}
 	dlConfig = *config
+	if dlOpts.format != core.FormatMarkdown && dlOpts.format != core.FormatHTML &&
+		dlOpts.format != core.FormatJSON {
+		return fmt.Errorf("unsupported format: %s", dlOpts.format)
+	}
+	if dlPreset, err = applyPreset(&dlConfig.Output, dlOpts.preset); err != nil {
+		return err
+	}
 
 	// Instantiate the client
 	client := core.NewClient(dlConfig.Feishu)
@@ -275,10 +595,20 @@ func handleDownloadCommand(url string) error {
 
 	// 执行下载
 	if dlOpts.batch {
-		return downloadDocuments(ctx, client, url)
+		err = downloadDocuments(ctx, client, url)
 	} else if dlOpts.wiki {
-		return downloadWiki(ctx, client, url)
+		err = downloadWiki(ctx, client, url)
 	} else {
-		return downloadDocument(ctx, client, url, &dlOpts)
+		err = downloadDocument(ctx, client, url, &dlOpts)
+	}
+
+	// 汇总因不支持而被丢弃或未能完整导出的内容
+	if blocks, documents := dlDropped.count(); blocks > 0 {
+		fmt.Fprintf(os.Stderr, "⚠ %d block(s) dropped or not fully exported in %d document(s):\n", blocks, documents)
+		dlDropped.print(os.Stderr)
+		if err == nil && dlOpts.strict {
+			err = fmt.Errorf("content was dropped or not fully exported from %d document(s) (--strict)", documents)
+		}
 	}
+	return err
 }
@@ -84,6 +84,24 @@ func main() {
 						Usage:       "Download all documents within the wiki.",
 						Destination: &dlOpts.wiki,
 					},
+					&cli.StringFlag{
+						Name:        "preset",
+						Value:       "",
+						Usage:       "Apply an output preset: docusaurus, hexo, hugo, mkdocs, obsidian, vitepress",
+						Destination: &dlOpts.preset,
+					},
+					&cli.StringFlag{
+						Name:        "format",
+						Value:       "markdown",
+						Usage:       "Output format: markdown, html, or json for the document AST",
+						Destination: &dlOpts.format,
+					},
+					&cli.BoolFlag{
+						Name:        "strict",
+						Value:       false,
+						Usage:       "Fail the run if any block was dropped or could not be fully exported",
+						Destination: &dlOpts.strict,
+					},
 				},
 				ArgsUsage: "<url>",
 				Action: func(ctx *cli.Context) error {
@@ -145,6 +163,30 @@ func main() {
 						Usage:       "Dump json response of the OPEN API",
 						Destination: &syncOpts.dump,
 					},
+					&cli.StringFlag{
+						Name:        "preset",
+						Value:       "",
+						Usage:       "Apply an output preset: docusaurus, hexo, hugo, mkdocs, obsidian, vitepress",
+						Destination: &syncOpts.preset,
+					},
+					&cli.StringFlag{
+						Name:        "format",
+						Value:       "markdown",
+						Usage:       "Output format: markdown or html",
+						Destination: &syncOpts.format,
+					},
+					&cli.BoolFlag{
+						Name:        "strict",
+						Value:       false,
+						Usage:       "Fail the run if any block was dropped or could not be fully exported",
+						Destination: &syncOpts.strict,
+					},
+					&cli.BoolFlag{
+						Name:        "prune-assets",
+						Value:       false,
+						Usage:       "Delete downloaded images no longer referenced by any synced document",
+						Destination: &syncOpts.pruneAssets,
+					},
 				},
 				ArgsUsage: "[url]",
 				Action: func(ctx *cli.Context) error {
@@ -160,6 +202,31 @@ func main() {
 					return handleSyncCommand(url)
 				},
 			},
+			{
+				Name:  "upload",
+				Usage: "Upload a markdown file to feishu/larksuite as a new or overwritten document",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:        "title",
+						Value:       "",
+						Usage:       "Document title (default: the leading level 1 heading or the file name)",
+						Destination: &uploadOpts.title,
+					},
+					&cli.BoolFlag{
+						Name:        "overwrite",
+						Value:       false,
+						Usage:       "Overwrite the docx document, or the document of the wiki node instead of creating a child node",
+						Destination: &uploadOpts.overwrite,
+					},
+				},
+				ArgsUsage: "<file> <folder/docx/wiki url>",
+				Action: func(ctx *cli.Context) error {
+					if ctx.NArg() < 2 {
+						return cli.Exit("Please specify the markdown file and the target folder/docx/wiki url", 1)
+					}
+					return handleUploadCommand(ctx.Args().Get(0), ctx.Args().Get(1))
+				},
+			},
 		},
 	}
 
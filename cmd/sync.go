@@ -16,16 +16,25 @@ import (
 
 type SyncOpts struct {
 	outputDir   string
+	rootDir     string // 同步的根目录，image_layout 为 shared 时图片保存在其中
 	incremental bool   // 增量同步，默认开启
 	force       bool   // 强制重新下载
 	include     string // 仅下载匹配的目录（白名单，逗号分隔）
 	exclude     string // 排除匹配的目录（黑名单，逗号分隔）
 	concurrency int    // 并发数
 	dump        bool   // 导出 JSON 响应
+	preset      string // 静态站点生成器预设
+	format      string // 输出格式：markdown 或 html
+	strict      bool   // 有内容因不支持而被丢弃或未能完整导出时返回错误
+	fileName    string // 覆盖输出文件名，用于预设的目录文档
+	position    int    // 在知识库同级节点中的位置
+	pruneAssets bool   // 删除不再被任何文档引用的图片
 }
 
 var syncOpts = SyncOpts{}
 var syncConfig core.Config
+var syncPreset *core.Preset
+var syncDropped droppedBlocks
 
 // syncDocument 同步单个文档
 func syncDocument(ctx context.Context, client *core.Client, url string, opts *SyncOpts, cacheManager *core.CacheManager) error {
@@ -36,7 +45,9 @@ func syncDocument(ctx context.Context, client *core.Client, url string, opts *Sy
 	}
 
 	// for a wiki page, we need to renew docType and docToken first
+	var nodeToken string
 	if docType == "wiki" {
+		nodeToken = docToken
 		node, err := client.GetWikiNodeInfo(ctx, docToken)
 		if err != nil {
 			err = fmt.Errorf("GetWikiNodeInfo err: %v for %v", err, url)
@@ -47,7 +58,7 @@ func syncDocument(ctx context.Context, client *core.Client, url string, opts *Sy
 	}
 
 	// Process the download
-	docx, blocks, err := client.GetDocxContent(ctx, docToken)
+	docx, blocks, properties, err := client.GetDocxContent(ctx, docToken)
 	utils.CheckErr(err)
 
 	title := docx.Title
@@ -60,6 +71,12 @@ func syncDocument(ctx context.Context, client *core.Client, url string, opts *Sy
 	} else {
 		mdName = fmt.Sprintf("%s.md", docToken)
 	}
+	if opts.fileName != "" {
+		mdName = opts.fileName
+	}
+	if opts.format == core.FormatHTML {
+		mdName = strings.TrimSuffix(mdName, ".md") + ".html"
+	}
 	outputPath := filepath.Join(opts.outputDir, mdName)
 
 	// 增量下载逻辑：检查是否需要下载
@@ -80,33 +97,64 @@ func syncDocument(ctx context.Context, client *core.Client, url string, opts *Sy
 				mdName,
 				docType,
 			)
+			cacheManager.UpdateDocumentLocation(docToken, nodeToken, outputPath)
 			return nil
 		}
 	}
 
 	// 继续执行下载流程
 	parser := core.NewParser(syncConfig.Output)
+	parser.SetBlockProperties(properties)
+	parser.SetEmbedFetcher(ctx, client)
+	userResolver := core.NewCachedUserResolver(client, cacheManager)
+	parser.SetUserResolver(userResolver)
+	parser.SetBaseURL(utils.ExtractBaseURL(url))
+
+	// 解析时下载图片和附件
+	rootDir := opts.rootDir
+	if rootDir == "" {
+		rootDir = opts.outputDir
+	}
+	assets := &assetDownloader{
+		ctx:        ctx,
+		client:     client,
+		output:     syncConfig.Output,
+		format:     opts.format,
+		documentID: docx.DocumentID,
+		outputDir:  opts.outputDir,
+		imageDir:   syncConfig.Output.DocImageDir(rootDir, outputPath),
+		docDir:     filepath.Dir(outputPath),
+		warn: func(blockID string, err error) {
+			fmt.Fprintf(os.Stderr, "警告: 画板 %s 导出失败: %v\n", blockID, err)
+		},
+		cache: cacheManager,
+	}
+	parser.SetAssetResolver(assets.resolve)
+
+	var content string
+	if opts.format == core.FormatHTML {
+		content = core.NewHTMLEmitter(syncConfig.Output).Emit(parser.BuildDocument(docx, blocks))
+	} else {
+		content = parser.ParseDocxContent(docx, blocks)
+	}
+	if assets.err != nil {
+		return assets.err
+	}
 
-	markdown := parser.ParseDocxContent(docx, blocks)
-
-	if !syncConfig.Output.SkipImgDownload {
-		for _, imgToken := range parser.ImgTokens {
-			localLink, err := client.DownloadImage(
-				ctx, imgToken, filepath.Join(opts.outputDir, syncConfig.Output.ImageDir),
-			)
-			if err != nil {
-				return err
-			}
-			markdown = strings.Replace(markdown, imgToken, localLink, 1)
+	// Format the output document
+	var result string
+	if opts.format == core.FormatHTML {
+		if result, err = renderHTMLPage(syncConfig.Output, title, content); err != nil {
+			return err
 		}
+	} else {
+		engine := lute.New(func(l *lute.Lute) {
+			l.RenderOptions.AutoSpace = true
+		})
+		result = engine.FormatStr("md", content)
+		result = renderFrontMatter(ctx, client, userResolver, syncConfig.Output, docx, url, opts.position) + result
 	}
 
-	// Format the markdown document
-	engine := lute.New(func(l *lute.Lute) {
-		l.RenderOptions.AutoSpace = true
-	})
-	result := engine.FormatStr("md", markdown)
-
 	// Handle the output directory and name
 	if _, err := os.Stat(opts.outputDir); os.IsNotExist(err) {
 		if err := os.MkdirAll(opts.outputDir, 0o755); err != nil {
@@ -118,11 +166,13 @@ func syncDocument(ctx context.Context, client *core.Client, url string, opts *Sy
 		jsonName := fmt.Sprintf("%s.json", docToken)
 		jsonOutputPath := filepath.Join(opts.outputDir, jsonName)
 		data := struct {
-			Document *lark.DocxDocument `json:"document"`
-			Blocks   []*lark.DocxBlock  `json:"blocks"`
+			Document   *lark.DocxDocument       `json:"document"`
+			Blocks     []*lark.DocxBlock        `json:"blocks"`
+			Properties core.DocxBlockProperties `json:"properties"`
 		}{
-			Document: docx,
-			Blocks:   blocks,
+			Document:   docx,
+			Blocks:     blocks,
+			Properties: properties,
 		}
 		pdata := utils.PrettyPrint(data)
 
@@ -132,14 +182,23 @@ func syncDocument(ctx context.Context, client *core.Client, url string, opts *Sy
 		fmt.Printf("Dumped json response to %s\n", jsonOutputPath)
 	}
 
-	// Write to markdown file
+	// Write the sidecar files next to the markdown file
+	for _, sidecar := range parser.Sidecars {
+		sidecarPath := filepath.Join(opts.outputDir, sidecar.Name)
+		if err = os.WriteFile(sidecarPath, sidecar.Content, 0o644); err != nil {
+			return err
+		}
+	}
+
+	// Write to output file
 	if err = os.WriteFile(outputPath, []byte(result), 0o644); err != nil {
 		return err
 	}
 	fmt.Printf("✓ 已同步: %s\n", outputPath)
+	syncDropped.add(outputPath, parser.Diagnostics)
 
-	// 更新缓存
-	if cacheManager != nil {
+	// 更新缓存。严格模式下不缓存丢弃了内容或未能完整导出的文档，下次同步时重新下载
+	if cacheManager != nil && !(opts.strict && len(parser.Diagnostics) > 0) {
 		cacheManager.UpdateDocument(
 			docToken,
 			revisionID,
@@ -147,6 +206,8 @@ func syncDocument(ctx context.Context, client *core.Client, url string, opts *Sy
 			mdName,
 			docType,
 		)
+		cacheManager.UpdateDocumentLocation(docToken, nodeToken, outputPath)
+		cacheManager.UpdateDocumentImages(docToken, assets.images)
 	}
 
 	return nil
@@ -175,10 +236,13 @@ func syncFolder(ctx context.Context, client *core.Client, url string, opts *Sync
 		}
 		docOpts := &SyncOpts{
 			outputDir:   folderPath,
+			rootDir:     opts.outputDir,
 			dump:        opts.dump,
 			incremental: opts.incremental,
 			force:       opts.force,
 			concurrency: opts.concurrency,
+			format:      opts.format,
+			strict:      opts.strict,
 		}
 		for _, file := range files {
 			if file.Type == "folder" {
@@ -251,18 +315,22 @@ func syncWiki(ctx context.Context, client *core.Client, url string, opts *SyncOp
 		client *core.Client,
 		spaceID string,
 		parentPath string,
-		parentNodeToken *string) error
+		parentNodeToken *string) ([]*core.NavNode, error)
 
+	rootPath := folderPath
 	downloadWikiNode = func(ctx context.Context,
 		client *core.Client,
 		spaceID string,
 		folderPath string,
-		parentNodeToken *string) error {
+		parentNodeToken *string) ([]*core.NavNode, error) {
 		nodes, err := client.GetWikiNodeList(ctx, spaceID, parentNodeToken)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		for _, n := range nodes {
+		var nav []*core.NavNode
+		for i, n := range nodes {
+			navNode := &core.NavNode{Title: n.Title, Position: i + 1}
+			docPath := folderPath
 			if n.HasChild {
 				// 检查目录是否应该被下载
 				if filter != nil {
@@ -277,9 +345,16 @@ func syncWiki(ctx context.Context, client *core.Client, url string, opts *SyncOp
 					}
 				}
 				_folderPath := filepath.Join(folderPath, n.Title)
-				if err := downloadWikiNode(ctx, client,
-					spaceID, _folderPath, &n.NodeToken); err != nil {
-					return err
+				children, err := downloadWikiNode(ctx, client,
+					spaceID, _folderPath, &n.NodeToken)
+				if err != nil {
+					return nil, err
+				}
+				navNode.Dir = navPath(rootPath, _folderPath)
+				navNode.Children = children
+				// 预设要求目录文档保存在子目录中，如 index 文件或 folder note
+				if syncPreset != nil && syncPreset.IndexInFolder() {
+					docPath = _folderPath
 				}
 			}
 			if n.ObjType == "docx" {
@@ -288,11 +363,19 @@ func syncWiki(ctx context.Context, client *core.Client, url string, opts *SyncOp
 					continue
 				}
 				docOpts := &SyncOpts{
-					outputDir:   folderPath,
+					outputDir:   docPath,
+					rootDir:     rootPath,
 					dump:        opts.dump,
 					incremental: opts.incremental,
 					force:       opts.force,
 					concurrency: opts.concurrency,
+					format:      opts.format,
+					strict:      opts.strict,
+					position:    i + 1,
+				}
+				if syncPreset != nil {
+					docOpts.fileName = syncPreset.DocFileName(n.Title, n.HasChild)
+					navNode.Path = navPath(rootPath, filepath.Join(docPath, docOpts.fileName))
 				}
 				wg.Add(1)
 				semaphore <- struct{}{}
@@ -306,11 +389,15 @@ func syncWiki(ctx context.Context, client *core.Client, url string, opts *SyncOp
 					}
 				}(prefixURL + "/wiki/" + n.NodeToken)
 			}
+			if navNode.Path != "" || len(navNode.Children) > 0 {
+				nav = append(nav, navNode)
+			}
 		}
-		return nil
+		return nav, nil
 	}
 
-	if err = downloadWikiNode(ctx, client, spaceID, folderPath, nil); err != nil {
+	nav, err := downloadWikiNode(ctx, client, spaceID, folderPath, nil)
+	if err != nil {
 		return err
 	}
 
@@ -322,6 +409,14 @@ func syncWiki(ctx context.Context, client *core.Client, url string, opts *SyncOp
 	for err := range errChan {
 		return err
 	}
+
+	// 生成静态站点生成器的导航文件
+	if syncPreset != nil && syncPreset.WriteNav != nil {
+		if err := syncPreset.WriteNav(folderPath, nav); err != nil {
+			return err
+		}
+		fmt.Printf("✓ 已生成 %s 导航: %s\n", syncPreset.Name, folderPath)
+	}
 	return nil
 }
 
@@ -351,6 +446,12 @@ func handleSyncCommand(url string) error {
 		return err
 	}
 	syncConfig = *config
+	if syncOpts.format != core.FormatMarkdown && syncOpts.format != core.FormatHTML {
+		return fmt.Errorf("不支持的输出格式: %s", syncOpts.format)
+	}
+	if syncPreset, err = applyPreset(&syncConfig.Output, syncOpts.preset); err != nil {
+		return err
+	}
 
 	// 尝试加载已有的同步配置
 	existingSyncConfig, err := core.LoadSyncConfig(syncOpts.outputDir)
@@ -453,6 +554,28 @@ func handleSyncCommand(url string) error {
 		syncErr = syncWiki(ctx, client, url, &syncOpts, cacheManager, nodeFilter)
 	}
 
+	// 改写已同步文档之间的链接，使导出的目录可以离线浏览
+	if syncErr == nil && cacheManager != nil {
+		rewritten, err := cacheManager.RewriteLocalDocLinks(syncConfig.Output.Flavor)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "警告: 改写文档链接失败: %v\n", err)
+		} else if rewritten > 0 {
+			fmt.Printf("✓ 已改写 %d 个文档中的链接\n", rewritten)
+		}
+	}
+
+	// 清理不再被引用的图片。严格模式下丢弃了内容的文档未被缓存，其引用的图片不能删除
+	blocks, _ := syncDropped.count()
+	if syncErr == nil && cacheManager != nil && syncOpts.pruneAssets && !(syncOpts.strict && blocks > 0) {
+		if count := cacheManager.UnrecordedImageDocuments(); count > 0 {
+			fmt.Fprintf(os.Stderr, "警告: %d 个文档没有图片记录，请使用 --force 重新同步后再清理图片\n", count)
+		} else if removed, err := cacheManager.PruneImages(); err != nil {
+			fmt.Fprintf(os.Stderr, "警告: 清理图片失败: %v\n", err)
+		} else {
+			fmt.Printf("✓ 已清理 %d 个不再被引用的图片\n", len(removed))
+		}
+	}
+
 	// 保存缓存
 	if cacheManager != nil {
 		if err := cacheManager.Save(); err != nil {
@@ -462,6 +585,15 @@ func handleSyncCommand(url string) error {
 		}
 	}
 
+	// 汇总因不支持而被丢弃或未能完整导出的内容
+	if blocks, documents := syncDropped.count(); blocks > 0 {
+		fmt.Fprintf(os.Stderr, "⚠ %d 个文档中共有 %d 个 Block 被丢弃或未能完整导出:\n", documents, blocks)
+		syncDropped.print(os.Stderr)
+		if syncErr == nil && syncOpts.strict {
+			syncErr = fmt.Errorf("%d 个文档中有内容被丢弃或未能完整导出（--strict）", documents)
+		}
+	}
+
 	// 保存同步配置
 	if syncErr == nil {
 		if err := currentSyncConfig.Save(syncOpts.outputDir); err != nil {
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/Wsine/feishu2md/core"
+	"github.com/Wsine/feishu2md/utils"
+)
+
+type UploadOpts struct {
+	title     string // 文档标题，默认为 Markdown 开头的一级标题或文件名
+	overwrite bool   // 覆盖 docx 文档或知识库节点本身的文档，而不是在其下新建子节点
+}
+
+var uploadOpts = UploadOpts{}
+
+// uploadTarget 根据目标链接新建或清空文档，返回文档 ID 和上传后的文档链接
+func uploadTarget(ctx context.Context, client *core.Client, url, title string) (string, string, error) {
+	baseURL := utils.ExtractBaseURL(url)
+	if folderToken, err := utils.ValidateFolderURL(url); err == nil {
+		if uploadOpts.overwrite {
+			return "", "", fmt.Errorf("--overwrite requires a docx or wiki url")
+		}
+		documentID, err := client.CreateDocument(ctx, folderToken, title)
+		if err != nil {
+			return "", "", err
+		}
+		return documentID, baseURL + "/docx/" + documentID, nil
+	}
+
+	docType, docToken, err := utils.ValidateDocumentURL(url)
+	if err != nil {
+		return "", "", err
+	}
+	switch docType {
+	case "docx":
+		// 指定文档时只能覆盖，需要显式加上 --overwrite，避免误清空已有文档
+		if !uploadOpts.overwrite {
+			return "", "", fmt.Errorf("uploading to a docx url replaces its content, add --overwrite to confirm")
+		}
+		return docToken, url, client.ClearDocument(ctx, docToken, title)
+	case "wiki":
+		node, err := client.GetWikiNodeInfo(ctx, docToken)
+		if err != nil {
+			return "", "", err
+		}
+		if uploadOpts.overwrite {
+			if node.ObjType != "docx" {
+				return "", "", fmt.Errorf("unsupported wiki node type: %s", node.ObjType)
+			}
+			return node.ObjToken, url, client.ClearDocument(ctx, node.ObjToken, title)
+		}
+		child, err := client.CreateWikiDocument(ctx, node.SpaceID, node.NodeToken, title)
+		if err != nil {
+			return "", "", err
+		}
+		return child.ObjToken, baseURL + "/wiki/" + child.NodeToken, nil
+	}
+	return "", "", fmt.Errorf("unsupported document type: %s", docType)
+}
+
+// readUploadImages 在创建文档之前读取全部图片，避免图片缺失时留下只上传了一半的文档
+func readUploadImages(dir string, blocks []*core.UploadBlock, images map[string][]byte) error {
+	for _, b := range blocks {
+		if src := b.ImageSrc; src != "" {
+			if _, ok := images[src]; !ok {
+				data, err := readUploadImage(dir, src)
+				if err != nil {
+					return fmt.Errorf("failed to read image %s: %w", src, err)
+				}
+				images[src] = data
+			}
+		}
+		if err := readUploadImages(dir, b.Children, images); err != nil {
+			return err
+		}
+		for _, cell := range b.Cells {
+			if err := readUploadImages(dir, cell, images); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// readUploadImage 读取网络图片或相对于 Markdown 文件所在目录的本地图片
+func readUploadImage(dir, src string) ([]byte, error) {
+	if strings.HasPrefix(src, "http://") || strings.HasPrefix(src, "https://") {
+		resp, err := http.Get(src)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("unexpected status: %s", resp.Status)
+		}
+		return io.ReadAll(resp.Body)
+	}
+	name := utils.UnescapeURL(src)
+	if !filepath.IsAbs(name) {
+		name = filepath.Join(dir, name)
+	}
+	return os.ReadFile(name)
+}
+
+func handleUploadCommand(file, url string) error {
+	// Load config
+	configPath, err := core.GetConfigFilePath()
+	if err != nil {
+		return err
+	}
+	config, err := core.ReadConfigFromFile(configPath)
+	if err != nil {
+		return err
+	}
+
+	// Convert the markdown file
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	title, blocks := core.ParseMarkdown(string(data))
+	if uploadOpts.title != "" {
+		title = uploadOpts.title
+	} else if title == "" {
+		title = strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	}
+	images := map[string][]byte{}
+	if err = readUploadImages(filepath.Dir(file), blocks, images); err != nil {
+		return err
+	}
+
+	// Instantiate the client
+	client := core.NewClient(config.Feishu)
+	ctx := context.Background()
+
+	documentID, docURL, err := uploadTarget(ctx, client, url, title)
+	if err != nil {
+		return err
+	}
+	err = client.UploadBlocks(ctx, documentID, blocks, func(src string) (string, []byte, error) {
+		name, _, _ := strings.Cut(src, "?")
+		return path.Base(name), images[src], nil
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✓ Uploaded %s to %s\n", file, docURL)
+	return nil
+}
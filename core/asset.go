@@ -0,0 +1,41 @@
+package core
+
+// 文档中引用的资源类型
+const (
+	AssetImage = "image" // 图片，Token 为图片 token
+	AssetBoard = "board" // 画板和流程图/UML 导出的图片，Token 为 Block ID
+	AssetFile  = "file"  // 附件，Token 为文件 token
+)
+
+// Asset 文档中对图片、画板或附件的一处引用
+type Asset struct {
+	Kind  string
+	Token string
+	Name  string // 附件的文件名，图片为空
+}
+
+// AssetResolver 返回资源在输出文档中的链接，如相对 Markdown 文件的路径或 data URI。
+// 每处引用调用一次，同一资源被多次引用时由调用方决定是否复用结果。
+// 画板无法导出为图片时返回空字符串，文档中改为输出指向原文档的链接并记录到 Parser.Diagnostics
+type AssetResolver func(asset *Asset) string
+
+// SetAssetResolver 设置资源链接的解析方式，未设置时以 token 原样输出，由调用方自行替换
+func (p *Parser) SetAssetResolver(resolver AssetResolver) {
+	p.assetResolver = resolver
+}
+
+// assetAttrs 为资源节点的属性加上解析后的链接 src，未设置 AssetResolver 时保持不变
+func (p *Parser) assetAttrs(kind string, attrs map[string]string) map[string]string {
+	if p.assetResolver != nil {
+		attrs["src"] = p.assetResolver(&Asset{Kind: kind, Token: attrs["token"], Name: attrs["name"]})
+	}
+	return attrs
+}
+
+// assetSrc 返回资源节点的链接，未经解析时为 token
+func assetSrc(attrs map[string]string) string {
+	if src, ok := attrs["src"]; ok {
+		return src
+	}
+	return attrs["token"]
+}
@@ -0,0 +1,467 @@
+package core
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/Wsine/feishu2md/utils"
+	"github.com/chyroc/lark"
+)
+
+// NodeType AST 节点的类型
+type NodeType string
+
+// Block 节点类型，注释中列出该类型使用的属性
+const (
+	NodeHeading     NodeType = "heading"     // level、anchor（仅开启 toc 或 heading_anchors 时）
+	NodeParagraph   NodeType = "paragraph"   //
+	NodeList        NodeType = "list"        // ordered、start，子节点均为 list_item
+	NodeListItem    NodeType = "list_item"   // checked（仅待办事项）
+	NodeCode        NodeType = "code"        // language，代码在 Text 中，带链接等样式的内容在 Inlines 中
+	NodeQuote       NodeType = "quote"       //
+	NodeCallout     NodeType = "callout"     // callout_type、emoji
+	NodeEquation    NodeType = "equation"    // 公式在 Text 中
+	NodeDivider     NodeType = "divider"     //
+	NodeImage       NodeType = "image"       // token、src、width、height、align、caption、source（画板为 board）
+	NodeFile        NodeType = "file"        // token、src、name
+	NodeTable       NodeType = "table"       // header_row，子节点均为 table_row
+	NodeTableRow    NodeType = "table_row"   // 子节点均为 table_cell，被合并的单元格不出现
+	NodeTableCell   NodeType = "table_cell"  // rowspan、colspan
+	NodeEmbed       NodeType = "embed"       // kind（sheet、bitable）、token、url、file（导出的附属文件），拉取到数据时子节点为 table
+	NodeHTML        NodeType = "html"        // HTML 片段在 Text 中，按原样输出，供自定义渲染器使用
+	NodeUnsupported NodeType = "unsupported" // block_type
+)
+
+// Inline 节点类型
+const (
+	NodeText          NodeType = "text"            // href、color、background_color，样式在 Marks 中
+	NodeMentionUser   NodeType = "mention_user"    // user_id、name、en_name、email
+	NodeMentionDoc    NodeType = "mention_doc"     // href，标题在 Text 中
+	NodeInlineFormula NodeType = "inline_equation" // 公式在 Text 中
+)
+
+// Mark 文本样式
+type Mark string
+
+const (
+	MarkBold          Mark = "bold"
+	MarkItalic        Mark = "italic"
+	MarkStrikethrough Mark = "strikethrough"
+	MarkUnderline     Mark = "underline"
+	MarkCode          Mark = "code"
+	MarkHighlight     Mark = "highlight" // 背景色，色值在 background_color 属性中
+)
+
+// Document 文档的 AST，可直接序列化为 JSON 供其他工具使用
+type Document struct {
+	ID       string    `json:"id"`
+	Title    []*Inline `json:"title"`
+	Children []*Block  `json:"children"`
+}
+
+// Block 块级节点
+type Block struct {
+	Type     NodeType          `json:"type"`
+	ID       string            `json:"id,omitempty"`
+	Attrs    map[string]string `json:"attrs,omitempty"`
+	Text     string            `json:"text,omitempty"`
+	Inlines  []*Inline         `json:"inlines,omitempty"`
+	Children []*Block          `json:"children,omitempty"`
+}
+
+// Inline 行内节点
+type Inline struct {
+	Type  NodeType          `json:"type"`
+	Text  string            `json:"text,omitempty"`
+	Marks []Mark            `json:"marks,omitempty"`
+	Attrs map[string]string `json:"attrs,omitempty"`
+}
+
+// HasMark 是否带有样式 m
+func (i *Inline) HasMark(m Mark) bool {
+	for _, mark := range i.Marks {
+		if mark == m {
+			return true
+		}
+	}
+	return false
+}
+
+// PlainText 返回行内节点的纯文本内容
+func PlainText(inlines []*Inline) string {
+	buf := new(strings.Builder)
+	for _, i := range inlines {
+		if i.Type == NodeMentionUser && i.Attrs["name"] != "" {
+			buf.WriteString("@" + i.Attrs["name"])
+			continue
+		}
+		buf.WriteString(i.Text)
+	}
+	return buf.String()
+}
+
+// BuildDocument 将文档的 Block 列表构建为 AST，同时收集图片、画板、附件和附属文件，
+// 每种 Block 由注册的 BlockRenderer 构建
+func (p *Parser) BuildDocument(doc *lark.DocxDocument, blocks []*lark.DocxBlock) *Document {
+	p.loadBlocks(doc, blocks)
+
+	document := &Document{ID: doc.DocumentID, Title: []*Inline{}, Children: []*Block{}}
+	page := p.blockMap[doc.DocumentID]
+	if page == nil {
+		return document
+	}
+	document.Title = append(document.Title, p.buildInlines(page.Page)...)
+	document.Children = append(document.Children, p.buildBlocks(page.Children)...)
+	return document
+}
+
+// buildBlocks 构建 ids 对应的 Block 节点，渲染器返回的相邻列表项合并为同一个列表
+func (p *Parser) buildBlocks(ids []string) []*Block {
+	var result []*Block
+	var list *Block
+	var listType lark.DocxBlockType
+	var lastNumber int
+	for _, id := range ids {
+		b := p.blockMap[id]
+		if b == nil {
+			continue
+		}
+		nodes := p.renderBlock(b)
+		if len(nodes) != 1 || nodes[0].Type != NodeListItem {
+			list = nil
+			result = append(result, nodes...)
+			continue
+		}
+
+		// 无序列表、有序列表和待办事项各自成为独立的列表，编号不连续的有序列表项也另起一个列表
+		number := 0
+		if b.BlockType == lark.DocxBlockTypeOrdered {
+			number = p.listNumber(b)
+		}
+		if list == nil || listType != b.BlockType || (number > 0 && number != lastNumber+1) {
+			listType = b.BlockType
+			ordered := strconv.FormatBool(b.BlockType == lark.DocxBlockTypeOrdered)
+			list = &Block{Type: NodeList, Attrs: map[string]string{"ordered": ordered}}
+			if b.BlockType == lark.DocxBlockTypeOrdered {
+				list.Attrs["start"] = strconv.Itoa(number)
+			}
+			result = append(result, list)
+		}
+		lastNumber = number
+		list.Children = append(list.Children, nodes[0])
+	}
+	return result
+}
+
+// buildPage 构建页面 Block，嵌套在正文中的页面输出为段落
+func (p *Parser) buildPage(b *lark.DocxBlock) []*Block {
+	node := &Block{Type: NodeParagraph, ID: b.BlockID, Inlines: p.buildInlines(b.Page)}
+	return append([]*Block{node}, p.buildBlocks(b.Children)...)
+}
+
+// buildText 构建文本段落，空段落不输出
+func (p *Parser) buildText(b *lark.DocxBlock) []*Block {
+	inlines := p.buildInlines(b.Text)
+	if len(inlines) == 0 {
+		return nil
+	}
+	return []*Block{{Type: NodeParagraph, ID: b.BlockID, Inlines: inlines}}
+}
+
+// buildHeading 构建一至九级标题，标题下的子 Block 紧随其后
+func (p *Parser) buildHeading(b *lark.DocxBlock) []*Block {
+	level := int(b.BlockType-lark.DocxBlockTypeHeading1) + 1
+	text := reflect.ValueOf(b).Elem().FieldByName(fmt.Sprintf("Heading%d", level))
+	node := &Block{Type: NodeHeading, ID: b.BlockID, Attrs: map[string]string{"level": strconv.Itoa(level)}}
+	if anchor, ok := p.anchors[b.BlockID]; ok {
+		node.Attrs["anchor"] = anchor
+	}
+	node.Inlines = p.buildInlines(text.Interface().(*lark.DocxBlockText))
+	return append([]*Block{node}, p.buildBlocks(b.Children)...)
+}
+
+// buildListItem 构建列表项，子 Block 挂在列表项下
+func (p *Parser) buildListItem(b *lark.DocxBlock) []*Block {
+	item := &Block{Type: NodeListItem, ID: b.BlockID, Children: p.buildBlocks(b.Children)}
+	switch b.BlockType {
+	case lark.DocxBlockTypeBullet:
+		item.Inlines = p.buildInlines(b.Bullet)
+	case lark.DocxBlockTypeOrdered:
+		item.Inlines = p.buildInlines(b.Ordered)
+	case lark.DocxBlockTypeTodo:
+		item.Inlines = p.buildInlines(b.Todo)
+		item.Attrs = map[string]string{"checked": strconv.FormatBool(b.Todo != nil && b.Todo.Style != nil && b.Todo.Style.Done)}
+	}
+	return []*Block{item}
+}
+
+// buildCode 构建代码块，代码按原样保留，不做 Markdown 转义
+func (p *Parser) buildCode(b *lark.DocxBlock) []*Block {
+	node := &Block{Type: NodeCode, ID: b.BlockID, Attrs: map[string]string{"language": ""}}
+	if b.Code != nil {
+		if b.Code.Style != nil {
+			node.Attrs["language"] = DocxCodeLang2MdStr[b.Code.Style.Language]
+		}
+		node.Inlines = p.buildInlines(b.Code)
+		node.Text = strings.TrimSpace(PlainText(node.Inlines))
+	}
+	return []*Block{node}
+}
+
+func (p *Parser) buildQuote(b *lark.DocxBlock) []*Block {
+	return []*Block{{
+		Type:     NodeQuote,
+		ID:       b.BlockID,
+		Children: []*Block{{Type: NodeParagraph, Inlines: p.buildInlines(b.Quote)}},
+	}}
+}
+
+func (p *Parser) buildQuoteContainer(b *lark.DocxBlock) []*Block {
+	return []*Block{{Type: NodeQuote, ID: b.BlockID, Children: p.buildBlocks(b.Children)}}
+}
+
+func (p *Parser) buildCallout(b *lark.DocxBlock) []*Block {
+	callout := b.Callout
+	if callout == nil {
+		callout = &lark.DocxBlockCallout{}
+	}
+	node := &Block{Type: NodeCallout, ID: b.BlockID, Attrs: map[string]string{"callout_type": p.calloutType(callout)}}
+	if emoji := calloutEmoji(callout.EmojiID); emoji != "" {
+		node.Attrs["emoji"] = emoji
+	}
+	node.Children = p.buildBlocks(b.Children)
+	return []*Block{node}
+}
+
+func (p *Parser) buildEquation(b *lark.DocxBlock) []*Block {
+	return []*Block{{Type: NodeEquation, ID: b.BlockID, Text: strings.TrimSuffix(PlainText(p.buildInlines(b.Equation)), "\n")}}
+}
+
+func (p *Parser) buildDivider(b *lark.DocxBlock) []*Block {
+	return []*Block{{Type: NodeDivider, ID: b.BlockID}}
+}
+
+func (p *Parser) buildImage(b *lark.DocxBlock) []*Block {
+	p.ImgTokens = append(p.ImgTokens, b.Image.Token)
+	return []*Block{{Type: NodeImage, ID: b.BlockID, Attrs: p.assetAttrs(AssetImage, p.imageAttrs(b))}}
+}
+
+// buildBoard 画板和流程图/UML Block 导出为图片，未设置 AssetResolver 时以 Block ID 占位，
+// 由调用方通过 Client.DownloadBoardImage 下载后替换为图片路径。
+// AssetResolver 无法导出时（如旧版流程图）输出指向原文档中该 Block 的链接，并记录到 Diagnostics
+func (p *Parser) buildBoard(b *lark.DocxBlock) []*Block {
+	p.BoardBlocks = append(p.BoardBlocks, b.BlockID)
+	attrs := p.assetAttrs(AssetBoard, map[string]string{"token": b.BlockID, "source": "board"})
+	if src, ok := attrs["src"]; ok && src == "" {
+		p.reportFailed(b, "board export failed")
+		attrs = p.embedAttrs("board", b.BlockID, fmt.Sprintf("/docx/%s#%s", p.documentID, b.BlockID))
+		return []*Block{{Type: NodeEmbed, ID: b.BlockID, Attrs: attrs}}
+	}
+	return []*Block{{Type: NodeImage, ID: b.BlockID, Attrs: attrs}}
+}
+
+func (p *Parser) buildFile(b *lark.DocxBlock) []*Block {
+	p.FileTokens = append(p.FileTokens, b.File.Token)
+	attrs := p.assetAttrs(AssetFile, map[string]string{"token": b.File.Token, "name": b.File.Name})
+	return []*Block{{Type: NodeFile, ID: b.BlockID, Attrs: attrs}}
+}
+
+// buildChildren 视图、分栏中的列等容器展开为其子节点
+func (p *Parser) buildChildren(b *lark.DocxBlock) []*Block {
+	return p.buildBlocks(b.Children)
+}
+
+// buildGrid 分栏按列的顺序展开为各列的子节点
+func (p *Parser) buildGrid(b *lark.DocxBlock) []*Block {
+	var children []*Block
+	for _, column := range b.Children {
+		if columnBlock := p.blockMap[column]; columnBlock != nil {
+			children = append(children, p.buildBlocks(columnBlock.Children)...)
+		}
+	}
+	return children
+}
+
+// buildTable 构建表格节点，合并单元格的跨度记录在左上角的单元格上
+func (p *Parser) buildTable(b *lark.DocxBlock) []*Block {
+	table := &Block{
+		Type:  NodeTable,
+		ID:    b.BlockID,
+		Attrs: map[string]string{"header_row": strconv.FormatBool(p.tableHeaderRow(b.BlockID))},
+	}
+	t := b.Table
+	if t == nil || t.Property == nil || t.Property.ColumnSize <= 0 {
+		return []*Block{table}
+	}
+	columns := int(t.Property.ColumnSize)
+
+	covered := map[int]bool{}
+	var row *Block
+	for i, cellID := range t.Cells {
+		if i%columns == 0 {
+			row = &Block{Type: NodeTableRow}
+			table.Children = append(table.Children, row)
+		}
+		if covered[i] {
+			continue
+		}
+		cell := &Block{Type: NodeTableCell, ID: cellID}
+		if cellBlock := p.blockMap[cellID]; cellBlock != nil {
+			cell.Children = p.buildBlocks(cellBlock.Children)
+		}
+		if i < len(t.Property.MergeInfo) {
+			if merge := t.Property.MergeInfo[i]; merge != nil && (merge.RowSpan > 1 || merge.ColSpan > 1) {
+				cell.Attrs = mergeAttrs(merge)
+				for r := 0; r < int(max(merge.RowSpan, 1)); r++ {
+					for c := 0; c < int(max(merge.ColSpan, 1)); c++ {
+						covered[i+r*columns+c] = true
+					}
+				}
+			}
+		}
+		row.Children = append(row.Children, cell)
+	}
+	return []*Block{table}
+}
+
+// embedTable 构建内嵌内容的表格节点，首行为表头，merges 以左上角单元格的行列下标记录合并信息
+func embedTable(rows [][][]*Inline, merges map[int64]map[int64]*lark.DocxBlockTablePropertyMergeInfo) *Block {
+	table := &Block{Type: NodeTable, Attrs: map[string]string{"header_row": "true"}}
+	covered := map[[2]int64]bool{}
+	for r, cells := range rows {
+		row := &Block{Type: NodeTableRow}
+		for c, inlines := range cells {
+			if covered[[2]int64{int64(r), int64(c)}] {
+				continue
+			}
+			cell := &Block{Type: NodeTableCell}
+			if len(inlines) > 0 {
+				cell.Children = []*Block{{Type: NodeParagraph, Inlines: inlines}}
+			}
+			if merge := merges[int64(r)][int64(c)]; merge != nil {
+				cell.Attrs = mergeAttrs(merge)
+				for dr := int64(0); dr < max(merge.RowSpan, 1); dr++ {
+					for dc := int64(0); dc < max(merge.ColSpan, 1); dc++ {
+						covered[[2]int64{int64(r) + dr, int64(c) + dc}] = true
+					}
+				}
+			}
+			row.Children = append(row.Children, cell)
+		}
+		table.Children = append(table.Children, row)
+	}
+	return table
+}
+
+// mergeAttrs 返回合并单元格的 rowspan、colspan 属性，跨度为 1 的方向不记录
+func mergeAttrs(merge *lark.DocxBlockTablePropertyMergeInfo) map[string]string {
+	attrs := map[string]string{}
+	if merge.RowSpan > 1 {
+		attrs["rowspan"] = strconv.FormatInt(merge.RowSpan, 10)
+	}
+	if merge.ColSpan > 1 {
+		attrs["colspan"] = strconv.FormatInt(merge.ColSpan, 10)
+	}
+	return attrs
+}
+
+// buildInlines 构建文本的行内节点，样式相同的相邻片段会被合并
+func (p *Parser) buildInlines(text *lark.DocxBlockText) []*Inline {
+	var inlines []*Inline
+	if text == nil {
+		return inlines
+	}
+	for _, e := range mergeDocxTextElements(text.Elements) {
+		if inline := p.buildInline(e); inline != nil {
+			inlines = append(inlines, inline)
+		}
+	}
+	return inlines
+}
+
+// buildInline 构建单个行内节点，不支持的片段返回 nil
+func (p *Parser) buildInline(e *lark.DocxTextElement) *Inline {
+	switch {
+	case e.TextRun != nil:
+		return p.textRunInline(e.TextRun)
+	case e.MentionUser != nil:
+		return p.mentionUserInline(e.MentionUser.UserID)
+	case e.MentionDoc != nil:
+		return &Inline{
+			Type:  NodeMentionDoc,
+			Text:  e.MentionDoc.Title,
+			Attrs: map[string]string{"href": p.rewriteAnchorLink(utils.UnescapeURL(e.MentionDoc.URL))},
+		}
+	case e.Equation != nil:
+		return &Inline{Type: NodeInlineFormula, Text: strings.TrimSuffix(e.Equation.Content, "\n")}
+	}
+	return nil
+}
+
+func (p *Parser) textRunInline(tr *lark.DocxTextElementTextRun) *Inline {
+	inline := &Inline{Type: NodeText, Text: tr.Content}
+	style := tr.TextElementStyle
+	if style == nil {
+		return inline
+	}
+	for _, m := range []struct {
+		enabled bool
+		mark    Mark
+	}{
+		{style.Bold, MarkBold},
+		{style.Italic, MarkItalic},
+		{style.Strikethrough, MarkStrikethrough},
+		{style.Underline, MarkUnderline},
+		{style.InlineCode, MarkCode},
+		{style.BackgroundColor != 0, MarkHighlight},
+	} {
+		if m.enabled {
+			inline.Marks = append(inline.Marks, m.mark)
+		}
+	}
+	attrs := map[string]string{}
+	if style.Link != nil {
+		attrs["href"] = p.rewriteAnchorLink(utils.UnescapeURL(style.Link.URL))
+	}
+	if color, ok := p.textColors[int(style.TextColor)]; ok && style.TextColor != 0 {
+		attrs["color"] = color
+	}
+	if color, ok := p.backgroundColors[int(style.BackgroundColor)]; ok && style.BackgroundColor != 0 {
+		attrs["background_color"] = color
+	}
+	if len(attrs) > 0 {
+		inline.Attrs = attrs
+	}
+	return inline
+}
+
+func (p *Parser) mentionUserInline(openID string) *Inline {
+	inline := &Inline{Type: NodeMentionUser, Attrs: map[string]string{"user_id": openID}}
+	if user, ok := p.users[openID]; ok {
+		for key, value := range map[string]string{"name": user.Name, "en_name": user.EnName, "email": user.Email} {
+			if value != "" {
+				inline.Attrs[key] = value
+			}
+		}
+	}
+	return inline
+}
+
+// splitEmbedToken 拆分内嵌内容的 token，格式为 <文件 token>_<子表 ID>
+func splitEmbedToken(token string) (string, string) {
+	if idx := strings.LastIndex(token, "_"); idx > 0 {
+		return token[:idx], token[idx+1:]
+	}
+	return token, ""
+}
+
+// embedAttrs 内嵌内容节点的属性，设置了站点地址时包含原始链接
+func (p *Parser) embedAttrs(kind, token, path string) map[string]string {
+	attrs := map[string]string{"kind": kind, "token": token}
+	if p.baseURL != "" {
+		attrs["url"] = p.baseURL + path
+	}
+	return attrs
+}
@@ -0,0 +1,202 @@
+package core_test
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/Wsine/feishu2md/core"
+	"github.com/Wsine/feishu2md/utils"
+	"github.com/chyroc/lark"
+	"github.com/stretchr/testify/assert"
+)
+
+func astTestDocx() (*lark.DocxDocument, []*lark.DocxBlock) {
+	return newTestDocx(
+		&lark.DocxBlock{
+			BlockID:   "page",
+			BlockType: lark.DocxBlockTypePage,
+			Page:      textBlock("AST"),
+			Children:  []string{"heading", "text", "bullet", "todo", "code", "table"},
+		},
+		&lark.DocxBlock{
+			BlockID:   "heading",
+			ParentID:  "page",
+			BlockType: lark.DocxBlockTypeHeading2,
+			Heading2:  textBlock("概述"),
+		},
+		&lark.DocxBlock{
+			BlockID:   "text",
+			ParentID:  "page",
+			BlockType: lark.DocxBlockTypeText,
+			Text: &lark.DocxBlockText{Elements: []*lark.DocxTextElement{
+				{TextRun: &lark.DocxTextElementTextRun{Content: "见 "}},
+				{TextRun: &lark.DocxTextElementTextRun{
+					Content: "文档 ",
+					TextElementStyle: &lark.DocxTextElementStyle{
+						Bold: true,
+						Link: &lark.DocxTextElementStyleLink{URL: "https%3A%2F%2Fexample.com"},
+					},
+				}},
+				{Equation: &lark.DocxTextElementEquation{Content: "E=mc^2\n"}},
+			}},
+		},
+		&lark.DocxBlock{
+			BlockID:   "bullet",
+			ParentID:  "page",
+			BlockType: lark.DocxBlockTypeBullet,
+			Bullet:    textBlock("步骤"),
+			Children:  []string{"ordered1", "ordered2"},
+		},
+		&lark.DocxBlock{
+			BlockID:   "ordered1",
+			ParentID:  "bullet",
+			BlockType: lark.DocxBlockTypeOrdered,
+			Ordered:   textBlock("安装"),
+		},
+		&lark.DocxBlock{
+			BlockID:   "ordered2",
+			ParentID:  "bullet",
+			BlockType: lark.DocxBlockTypeOrdered,
+			Ordered:   textBlock("配置"),
+		},
+		&lark.DocxBlock{
+			BlockID:   "todo",
+			ParentID:  "page",
+			BlockType: lark.DocxBlockTypeTodo,
+			Todo: &lark.DocxBlockText{
+				Elements: textBlock("发布").Elements,
+				Style:    &lark.DocxTextStyle{Done: true},
+			},
+		},
+		&lark.DocxBlock{
+			BlockID:   "code",
+			ParentID:  "page",
+			BlockType: lark.DocxBlockTypeCode,
+			Code: &lark.DocxBlockText{
+				Elements: textBlock("go build ./...").Elements,
+				Style:    &lark.DocxTextStyle{Language: lark.DocxCodeLanguageBash},
+			},
+		},
+		&lark.DocxBlock{
+			BlockID:   "table",
+			ParentID:  "page",
+			BlockType: lark.DocxBlockTypeTable,
+			Table: &lark.DocxBlockTable{
+				Cells: []string{"c1", "c2", "c3", "c4"},
+				Property: &lark.DocxBlockTableProperty{
+					RowSize:    2,
+					ColumnSize: 2,
+					MergeInfo: []*lark.DocxBlockTablePropertyMergeInfo{
+						{RowSpan: 1, ColSpan: 2}, {RowSpan: 1, ColSpan: 1},
+						{RowSpan: 1, ColSpan: 1}, {RowSpan: 1, ColSpan: 1},
+					},
+				},
+			},
+		},
+		&lark.DocxBlock{BlockID: "c1", BlockType: lark.DocxBlockTypeTableCell, Children: []string{"t1"}},
+		&lark.DocxBlock{BlockID: "c2", BlockType: lark.DocxBlockTypeTableCell},
+		&lark.DocxBlock{BlockID: "c3", BlockType: lark.DocxBlockTypeTableCell, Children: []string{"t3"}},
+		&lark.DocxBlock{BlockID: "c4", BlockType: lark.DocxBlockTypeTableCell, Children: []string{"t4"}},
+		&lark.DocxBlock{BlockID: "t1", BlockType: lark.DocxBlockTypeText, Text: textBlock("合并")},
+		&lark.DocxBlock{BlockID: "t3", BlockType: lark.DocxBlockTypeText, Text: textBlock("a")},
+		&lark.DocxBlock{BlockID: "t4", BlockType: lark.DocxBlockTypeText, Text: textBlock("b")},
+	)
+}
+
+func TestBuildDocument(t *testing.T) {
+	doc, blocks := astTestDocx()
+	parser := core.NewParser(core.NewConfig("", "").Output)
+	document := parser.BuildDocument(doc, blocks)
+
+	assert.Equal(t, "AST", core.PlainText(document.Title))
+	assert.Len(t, document.Children, 6)
+
+	heading := document.Children[0]
+	assert.Equal(t, core.NodeHeading, heading.Type)
+	assert.Equal(t, "2", heading.Attrs["level"])
+
+	text := document.Children[1].Inlines
+	assert.Len(t, text, 3)
+	assert.Equal(t, []core.Mark{core.MarkBold}, text[1].Marks)
+	assert.Equal(t, "https://example.com", text[1].Attrs["href"])
+	assert.Equal(t, core.NodeInlineFormula, text[2].Type)
+
+	// 列表项的子列表挂在列表项下，相邻的有序列表项合并为同一个列表
+	bullet := document.Children[2]
+	assert.Equal(t, core.NodeList, bullet.Type)
+	assert.Equal(t, "false", bullet.Attrs["ordered"])
+	assert.Len(t, bullet.Children[0].Children, 1)
+	assert.Len(t, bullet.Children[0].Children[0].Children, 2)
+
+	todo := document.Children[3]
+	assert.Equal(t, "true", todo.Children[0].Attrs["checked"])
+
+	code := document.Children[4]
+	assert.Equal(t, "bash", code.Attrs["language"])
+	assert.Equal(t, "go build ./...", code.Text)
+
+	// 被合并的单元格不出现在行中
+	table := document.Children[5]
+	assert.Len(t, table.Children, 2)
+	assert.Len(t, table.Children[0].Children, 1)
+	assert.Equal(t, "2", table.Children[0].Children[0].Attrs["colspan"])
+	assert.Len(t, table.Children[1].Children, 2)
+}
+
+func TestMarkdownEmitter(t *testing.T) {
+	doc, blocks := astTestDocx()
+	config := core.NewConfig("", "").Output
+	document := core.NewParser(config).BuildDocument(doc, blocks)
+
+	assert.Equal(t, "# AST\n\n"+
+		"## 概述\n\n"+
+		"见 [**文档**](https://example.com) $E=mc^2$\n\n"+
+		"- 步骤\n"+
+		"\t1. 安装\n"+
+		"\t2. 配置\n\n"+
+		"<!-- -->\n\n"+
+		"- [x] 发布\n\n"+
+		"```bash\ngo build ./...\n```\n\n"+
+		"<table>\n<tr>\n<td colspan=\"2\">合并</td></tr>\n<tr>\n<td>a</td><td>b</td></tr>\n</table>\n",
+		core.NewMarkdownEmitter(config).Emit(document))
+}
+
+func TestEmitHeadingLevel(t *testing.T) {
+	config := core.NewConfig("", "").Output
+	heading := &core.Block{
+		Type:    core.NodeHeading,
+		Attrs:   map[string]string{"level": "9"},
+		Inlines: []*core.Inline{{Type: core.NodeText, Text: "九级标题"}},
+	}
+	assert.Equal(t, "###### 九级标题\n", core.NewMarkdownEmitter(config).EmitBlock(heading))
+	assert.Equal(t, "<h6>九级标题</h6>\n", core.NewHTMLEmitter(config).EmitBlock(heading))
+}
+
+func TestDocumentJSON(t *testing.T) {
+	root := utils.RootDir()
+	for _, td := range []string{"testdocx.1", "testdocx.2", "testdocx.3"} {
+		t.Run(td, func(t *testing.T) {
+			jsonFile, err := os.Open(path.Join(root, "testdata", td+".json"))
+			utils.CheckErr(err)
+			defer jsonFile.Close()
+
+			data := struct {
+				Document *lark.DocxDocument `json:"document"`
+				Blocks   []*lark.DocxBlock  `json:"blocks"`
+			}{}
+			byteValue, _ := io.ReadAll(jsonFile)
+			json.Unmarshal(byteValue, &data)
+
+			document := core.NewParser(core.NewConfig("", "").Output).BuildDocument(data.Document, data.Blocks)
+			encoded, err := json.Marshal(document)
+			assert.NoError(t, err)
+
+			decoded := &core.Document{}
+			assert.NoError(t, json.Unmarshal(encoded, decoded))
+			assert.Equal(t, document, decoded)
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/chyroc/lark"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetBitableDataLinkedRecords(t *testing.T) {
+	c := NewClient(FeishuConfig{AppId: "app_id", AppSecret: "app_secret"})
+	c.larkClient.Mock().MockBitableGetBitableFieldList(func(ctx context.Context, req *lark.GetBitableFieldListReq, options ...lark.MethodOptionFunc) (*lark.GetBitableFieldListResp, *lark.Response, error) {
+		assert.Equal(t, "bascnApp", req.AppToken)
+		if req.TableID == "tblProjects" {
+			return &lark.GetBitableFieldListResp{Items: []*lark.GetBitableFieldListRespItem{
+				{FieldName: "Name", Type: 1},
+				{FieldName: "Owner", Type: 11},
+			}}, nil, nil
+		}
+		return &lark.GetBitableFieldListResp{Items: []*lark.GetBitableFieldListRespItem{
+			{FieldName: "Task", Type: 1},
+			{FieldName: "Project", Type: 18, Property: &lark.GetBitableFieldListRespItemProperty{TableID: "tblProjects"}},
+		}}, nil, nil
+	})
+	c.larkClient.Mock().MockBitableGetBitableRecordList(func(ctx context.Context, req *lark.GetBitableRecordListReq, options ...lark.MethodOptionFunc) (*lark.GetBitableRecordListResp, *lark.Response, error) {
+		if req.TableID == "tblProjects" {
+			return &lark.GetBitableRecordListResp{Items: []*lark.GetBitableRecordListRespItem{
+				{RecordID: "recA", Fields: map[string]interface{}{
+					"Name": []interface{}{map[string]interface{}{"type": "text", "text": "Alpha"}},
+				}},
+			}}, nil, nil
+		}
+		return &lark.GetBitableRecordListResp{Items: []*lark.GetBitableRecordListRespItem{
+			{RecordID: "rec1", Fields: map[string]interface{}{
+				"Task":    "Write spec",
+				"Project": map[string]interface{}{"link_record_ids": []interface{}{"recA", "recDeleted"}},
+			}},
+		}}, nil, nil
+	})
+
+	data, err := c.GetBitableData(context.Background(), "bascnApp", "tblTasks")
+	assert.NoError(t, err)
+	assert.Equal(t, []*BitableField{{Name: "Task", Type: 1}, {Name: "Project", Type: 18}}, data.Fields)
+	// 找不到的记录保留 ID
+	assert.Equal(t, []interface{}{"Alpha", "recDeleted"}, data.Records[0]["Project"])
+}
@@ -0,0 +1,122 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/chyroc/lark"
+)
+
+// DocxBlockTypeBoard 画板 Block，当前依赖的 lark 版本尚未定义该类型
+const DocxBlockTypeBoard lark.DocxBlockType = 43
+
+// getDocxBlockBoardResp lark.DocxBlock 中没有画板数据，单独解析 Block 以获取画板 token
+type getDocxBlockBoardResp struct {
+	Code int64  `json:"code,omitempty"`
+	Msg  string `json:"msg,omitempty"`
+	Data *struct {
+		Block *struct {
+			BlockID string `json:"block_id,omitempty"`
+			Board   *struct {
+				Token string `json:"token,omitempty"`
+			} `json:"board,omitempty"`
+		} `json:"block,omitempty"`
+	} `json:"data,omitempty"`
+}
+
+// downloadBoardImageResp 画板图片以文件形式返回
+type downloadBoardImageResp struct {
+	Code     int64  `json:"code,omitempty"`
+	Msg      string `json:"msg,omitempty"`
+	file     io.Reader
+	filename string
+}
+
+func (r *downloadBoardImageResp) SetReader(file io.Reader) {
+	r.file = file
+}
+
+func (r *downloadBoardImageResp) SetFilename(filename string) {
+	r.filename = filename
+}
+
+// getBoardToken 获取画板 Block 对应的画板 token，流程图/UML 等旧版绘图 Block 没有画板 token
+func (c *Client) getBoardToken(ctx context.Context, documentID, blockID string) (string, error) {
+	resp := new(getDocxBlockBoardResp)
+	_, err := c.larkClient.RawRequest(ctx, &lark.RawRequestReq{
+		Scope:  "Drive",
+		API:    "GetDocxBlock",
+		Method: "GET",
+		URL:    c.openBaseURL + "/open-apis/docx/v1/documents/:document_id/blocks/:block_id",
+		Body: &lark.GetDocxBlockReq{
+			DocumentID: documentID,
+			BlockID:    blockID,
+		},
+		MethodOption:          c.getMethodOption(),
+		NeedTenantAccessToken: true,
+		NeedUserAccessToken:   true,
+	}, resp)
+	if err != nil {
+		return "", err
+	}
+	if resp.Data == nil || resp.Data.Block == nil || resp.Data.Block.Board == nil || resp.Data.Block.Board.Token == "" {
+		return "", fmt.Errorf("block %s has no board token", blockID)
+	}
+	return resp.Data.Block.Board.Token, nil
+}
+
+// DownloadBoardImageRaw 获取画板渲染后的图片，返回其在 imgDir 下的路径和图片内容
+func (c *Client) DownloadBoardImageRaw(ctx context.Context, documentID, blockID, imgDir string) (string, []byte, error) {
+	boardToken, err := c.getBoardToken(ctx, documentID, blockID)
+	if err != nil {
+		return blockID, nil, err
+	}
+	resp := new(downloadBoardImageResp)
+	_, err = c.larkClient.RawRequest(ctx, &lark.RawRequestReq{
+		Scope:  "Board",
+		API:    "DownloadWhiteboardAsImage",
+		Method: "GET",
+		URL:    c.openBaseURL + "/open-apis/board/v1/whiteboards/:whiteboard_id/download_as_image",
+		Body: &struct {
+			WhiteboardID string `path:"whiteboard_id" json:"-"`
+		}{
+			WhiteboardID: boardToken,
+		},
+		MethodOption:          c.getMethodOption(),
+		NeedTenantAccessToken: true,
+		NeedUserAccessToken:   true,
+	}, resp)
+	if err != nil {
+		return blockID, nil, err
+	}
+	if resp.file == nil {
+		return blockID, nil, fmt.Errorf("empty image of board %s", boardToken)
+	}
+	fileext := filepath.Ext(resp.filename)
+	if fileext == "" {
+		fileext = ".png"
+	}
+	filename := fmt.Sprintf("%s/%s%s", imgDir, boardToken, fileext)
+	buf := new(bytes.Buffer)
+	buf.ReadFrom(resp.file)
+	return filename, buf.Bytes(), nil
+}
+
+// DownloadBoardImage 将画板渲染后的图片保存到 outDir，返回图片路径
+func (c *Client) DownloadBoardImage(ctx context.Context, documentID, blockID, outDir string) (string, error) {
+	filename, data, err := c.DownloadBoardImageRaw(ctx, documentID, blockID, outDir)
+	if err != nil {
+		return blockID, err
+	}
+	if err = os.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
+		return blockID, err
+	}
+	if err = os.WriteFile(filename, data, 0o644); err != nil {
+		return blockID, err
+	}
+	return filename, nil
+}
@@ -0,0 +1,50 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/chyroc/lark"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDownloadBoardImageRaw(t *testing.T) {
+	c := NewClient(FeishuConfig{AppId: "app_id", AppSecret: "app_secret"})
+	c.larkClient.Mock().MockRawRequest(func(ctx context.Context, req *lark.RawRequestReq, resp interface{}) (*lark.Response, error) {
+		switch req.API {
+		case "GetDocxBlock":
+			body := req.Body.(*lark.GetDocxBlockReq)
+			assert.Equal(t, "doxcnDocument", body.DocumentID)
+			assert.Equal(t, "doxcnBoardBlock", body.BlockID)
+			return nil, json.Unmarshal(
+				[]byte(`{"data":{"block":{"block_id":"doxcnBoardBlock","board":{"token":"wbcnBoard"}}}}`),
+				resp,
+			)
+		case "DownloadWhiteboardAsImage":
+			r := resp.(*downloadBoardImageResp)
+			r.SetReader(strings.NewReader("png-bytes"))
+			r.SetFilename("board.png")
+			return nil, nil
+		}
+		t.Fatalf("unexpected api %s", req.API)
+		return nil, nil
+	})
+
+	filename, data, err := c.DownloadBoardImageRaw(context.Background(), "doxcnDocument", "doxcnBoardBlock", "static")
+	assert.NoError(t, err)
+	assert.Equal(t, "static/wbcnBoard.png", filename)
+	assert.Equal(t, []byte("png-bytes"), data)
+}
+
+func TestDownloadBoardImageRawWithoutBoard(t *testing.T) {
+	c := NewClient(FeishuConfig{AppId: "app_id", AppSecret: "app_secret"})
+	c.larkClient.Mock().MockRawRequest(func(ctx context.Context, req *lark.RawRequestReq, resp interface{}) (*lark.Response, error) {
+		return nil, json.Unmarshal([]byte(`{"data":{"block":{"block_id":"doxcnDiagram"}}}`), resp)
+	})
+
+	filename, _, err := c.DownloadBoardImageRaw(context.Background(), "doxcnDocument", "doxcnDiagram", "static")
+	assert.Error(t, err)
+	assert.Equal(t, "doxcnDiagram", filename)
+}
@@ -14,18 +14,29 @@ const CacheVersion = "1.0"
 
 // DocumentCache 单个文档的缓存信息
 type DocumentCache struct {
-	RevisionID   int64     `json:"revision_id"`   // 文档版本号
-	Title        string    `json:"title"`         // 文档标题
-	FileName     string    `json:"file_name"`     // 实际保存的文件名
-	LastDownload time.Time `json:"last_download"` // 上次下载时间
-	DocType      string    `json:"doc_type"`      // 文档类型 (docx/wiki)
+	RevisionID   int64     `json:"revision_id"`          // 文档版本号
+	Title        string    `json:"title"`                // 文档标题
+	FileName     string    `json:"file_name"`            // 实际保存的文件名
+	LastDownload time.Time `json:"last_download"`        // 上次下载时间
+	DocType      string    `json:"doc_type"`             // 文档类型 (docx/wiki)
+	NodeToken    string    `json:"node_token,omitempty"` // 知识库节点 token
+	FilePath     string    `json:"file_path,omitempty"`  // 相对缓存所在目录的文件路径
+	Images       []string  `json:"images"`               // 引用的图片文件，相对缓存所在目录
+}
+
+// UserCache 单个用户的缓存信息
+type UserCache struct {
+	UserInfo
+	UpdatedAt time.Time `json:"updated_at"` // 查询时间
 }
 
 // CacheManager 缓存管理器
 type CacheManager struct {
-	Version   string                    `json:"version"`    // 缓存格式版本
-	UpdatedAt time.Time                 `json:"updated_at"` // 缓存更新时间
-	Documents map[string]*DocumentCache `json:"documents"`  // 文档token -> 缓存信息映射
+	Version   string                    `json:"version"`          // 缓存格式版本
+	UpdatedAt time.Time                 `json:"updated_at"`       // 缓存更新时间
+	Documents map[string]*DocumentCache `json:"documents"`        // 文档token -> 缓存信息映射
+	Users     map[string]*UserCache     `json:"users,omitempty"`  // 用户open_id -> 用户信息映射
+	Assets    map[string]*AssetCache    `json:"assets,omitempty"` // 图片token -> 已下载的图片文件
 
 	filePath string       // 缓存文件路径
 	mutex    sync.RWMutex // 读写锁保护并发访问
@@ -40,6 +51,8 @@ func NewCacheManager(outputDir string) (*CacheManager, error) {
 		Version:   CacheVersion,
 		UpdatedAt: time.Now(),
 		Documents: make(map[string]*DocumentCache),
+		Users:     make(map[string]*UserCache),
+		Assets:    make(map[string]*AssetCache),
 		filePath:  cachePath,
 		dirty:     false,
 	}
@@ -163,13 +176,35 @@ func (cm *CacheManager) UpdateDocument(
 	cm.mutex.Lock()
 	defer cm.mutex.Unlock()
 
-	cm.Documents[docToken] = &DocumentCache{
+	cache := &DocumentCache{
 		RevisionID:   revisionID,
 		Title:        title,
 		FileName:     fileName,
 		LastDownload: time.Now(),
 		DocType:      docType,
 	}
+	// 跳过下载的文档仍然引用原来的图片
+	if existing, exists := cm.Documents[docToken]; exists {
+		cache.Images = existing.Images
+	}
+	cm.Documents[docToken] = cache
+	cm.dirty = true
+}
+
+// UpdateDocumentLocation 记录文档的知识库节点 token 和本地文件路径，用于改写文档之间的链接
+func (cm *CacheManager) UpdateDocumentLocation(docToken, nodeToken, filePath string) {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+
+	cache, exists := cm.Documents[docToken]
+	if !exists {
+		return
+	}
+	if relPath, err := filepath.Rel(filepath.Dir(cm.filePath), filePath); err == nil {
+		filePath = relPath
+	}
+	cache.NodeToken = nodeToken
+	cache.FilePath = filepath.ToSlash(filePath)
 	cm.dirty = true
 }
 
@@ -193,6 +228,34 @@ func (cm *CacheManager) RemoveDocument(docToken string) {
 	}
 }
 
+// GetUser 获取未过期的用户缓存信息
+func (cm *CacheManager) GetUser(openID string) (*UserInfo, bool) {
+	cm.mutex.RLock()
+	defer cm.mutex.RUnlock()
+
+	cache, exists := cm.Users[openID]
+	if !exists || time.Since(cache.UpdatedAt) > userCacheTTL {
+		return nil, false
+	}
+	user := cache.UserInfo
+	return &user, true
+}
+
+// UpdateUser 更新用户缓存信息
+func (cm *CacheManager) UpdateUser(openID string, user *UserInfo) {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+
+	if cm.Users == nil {
+		cm.Users = make(map[string]*UserCache)
+	}
+	cm.Users[openID] = &UserCache{
+		UserInfo:  *user,
+		UpdatedAt: time.Now(),
+	}
+	cm.dirty = true
+}
+
 // GetStats 获取缓存统计信息
 func (cm *CacheManager) GetStats() (totalDocs int, oldestDownload time.Time) {
 	cm.mutex.RLock()
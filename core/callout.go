@@ -0,0 +1,124 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/chyroc/lark"
+)
+
+// 高亮块对应的 admonition 类型，兼容 GitHub 与 Obsidian
+const (
+	CalloutTypeNote      = "NOTE"
+	CalloutTypeTip       = "TIP"
+	CalloutTypeImportant = "IMPORTANT"
+	CalloutTypeWarning   = "WARNING"
+	CalloutTypeCaution   = "CAUTION"
+)
+
+// DefaultCalloutEmojiTypes 高亮块 emoji 到 admonition 类型的默认映射
+var DefaultCalloutEmojiTypes = map[string]string{
+	"bulb":                   CalloutTypeTip,
+	"white_check_mark":       CalloutTypeTip,
+	"heavy_check_mark":       CalloutTypeTip,
+	"star":                   CalloutTypeTip,
+	"memo":                   CalloutTypeNote,
+	"pushpin":                CalloutTypeNote,
+	"round_pushpin":          CalloutTypeNote,
+	"information_source":     CalloutTypeNote,
+	"speech_balloon":         CalloutTypeNote,
+	"exclamation":            CalloutTypeImportant,
+	"heavy_exclamation_mark": CalloutTypeImportant,
+	"fire":                   CalloutTypeImportant,
+	"dart":                   CalloutTypeImportant,
+	"warning":                CalloutTypeWarning,
+	"construction":           CalloutTypeWarning,
+	"x":                      CalloutTypeCaution,
+	"no_entry":               CalloutTypeCaution,
+	"no_entry_sign":          CalloutTypeCaution,
+	"rotating_light":         CalloutTypeCaution,
+}
+
+// DefaultCalloutColorTypes 高亮块背景色到 admonition 类型的默认映射，emoji 未匹配时使用
+var DefaultCalloutColorTypes = map[int]string{
+	1:  CalloutTypeCaution,   // 浅红色
+	2:  CalloutTypeWarning,   // 浅橙色
+	3:  CalloutTypeWarning,   // 浅黄色
+	4:  CalloutTypeTip,       // 浅绿色
+	5:  CalloutTypeNote,      // 浅蓝色
+	6:  CalloutTypeImportant, // 浅紫色
+	7:  CalloutTypeNote,      // 浅灰色
+	8:  CalloutTypeCaution,   // 暗红色
+	9:  CalloutTypeWarning,   // 暗橙色
+	10: CalloutTypeWarning,   // 暗黄色
+	11: CalloutTypeTip,       // 暗绿色
+	12: CalloutTypeNote,      // 暗蓝色
+	13: CalloutTypeImportant, // 暗紫色
+	14: CalloutTypeNote,      // 暗灰色
+}
+
+// calloutEmojis 常用的高亮块 emoji，未收录的 emoji 以 :emoji_id: 短代码输出
+var calloutEmojis = map[string]string{
+	"bulb":                   "💡",
+	"white_check_mark":       "✅",
+	"heavy_check_mark":       "✔️",
+	"star":                   "⭐",
+	"memo":                   "📝",
+	"pushpin":                "📌",
+	"round_pushpin":          "📍",
+	"information_source":     "ℹ️",
+	"speech_balloon":         "💬",
+	"exclamation":            "❗",
+	"heavy_exclamation_mark": "❗",
+	"fire":                   "🔥",
+	"dart":                   "🎯",
+	"warning":                "⚠️",
+	"construction":           "🚧",
+	"x":                      "❌",
+	"no_entry":               "⛔",
+	"no_entry_sign":          "🚫",
+	"rotating_light":         "🚨",
+	"gift":                   "🎁",
+	"smile":                  "😄",
+	"thumbsup":               "👍",
+	"rocket":                 "🚀",
+	"book":                   "📖",
+	"link":                   "🔗",
+	"calendar":               "📅",
+	"eyes":                   "👀",
+	"question":               "❓",
+}
+
+// calloutType 优先按 emoji、其次按背景色匹配 admonition 类型，均未匹配时为 TIP
+func (p *Parser) calloutType(c *lark.DocxBlockCallout) string {
+	if t, ok := p.calloutEmojiTypes[c.EmojiID]; ok && c.EmojiID != "" {
+		return strings.ToUpper(t)
+	}
+	if t, ok := p.calloutColorTypes[int(c.BackgroundColor)]; ok {
+		return strings.ToUpper(t)
+	}
+	return CalloutTypeTip
+}
+
+func calloutEmoji(emojiID string) string {
+	if emojiID == "" {
+		return ""
+	}
+	if emoji, ok := calloutEmojis[emojiID]; ok {
+		return emoji
+	}
+	return fmt.Sprintf(":%s:", emojiID)
+}
+
+// quoteLines 为每一行加上引用前缀，使多行内容都留在引用块中
+func quoteLines(content string) string {
+	buf := new(strings.Builder)
+	for _, line := range strings.Split(strings.TrimRight(content, "\n"), "\n") {
+		if line == "" {
+			buf.WriteString(">\n")
+		} else {
+			buf.WriteString("> " + line + "\n")
+		}
+	}
+	return buf.String()
+}
@@ -3,18 +3,24 @@ package core
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/chyroc/lark"
 	"github.com/chyroc/lark_rate_limiter"
 )
 
+const defaultOpenBaseURL = "https://open.feishu.cn"
+
 type Client struct {
 	larkClient      *lark.Lark
+	openBaseURL     string
 	authType        string
 	userAccessToken string
 }
@@ -22,9 +28,15 @@ type Client struct {
 func NewClient(config FeishuConfig) *Client {
 	var larkClient *lark.Lark
 
+	openBaseURL := strings.TrimSuffix(config.OpenBaseURL, "/")
+	if openBaseURL == "" {
+		openBaseURL = defaultOpenBaseURL
+	}
+
 	if config.AuthType == AuthTypeUser {
 		// 用户鉴权：不需要应用凭证
 		larkClient = lark.New(
+			lark.WithOpenBaseURL(openBaseURL),
 			lark.WithTimeout(60*time.Second),
 			lark.WithApiMiddleware(lark_rate_limiter.Wait(4, 4)),
 		)
@@ -32,6 +44,7 @@ func NewClient(config FeishuConfig) *Client {
 		// 应用鉴权（默认）
 		larkClient = lark.New(
 			lark.WithAppCredential(config.AppId, config.AppSecret),
+			lark.WithOpenBaseURL(openBaseURL),
 			lark.WithTimeout(60*time.Second),
 			lark.WithApiMiddleware(lark_rate_limiter.Wait(4, 4)),
 		)
@@ -39,6 +52,7 @@ func NewClient(config FeishuConfig) *Client {
 
 	return &Client{
 		larkClient:      larkClient,
+		openBaseURL:     openBaseURL,
 		authType:        config.AuthType,
 		userAccessToken: config.UserAccessToken,
 	}
@@ -52,51 +66,144 @@ func (c *Client) getMethodOptions() []lark.MethodOptionFunc {
 	return nil
 }
 
+// getMethodOption 返回直接调用 RawRequest 时使用的鉴权选项
+func (c *Client) getMethodOption() *lark.MethodOption {
+	option := new(lark.MethodOption)
+	for _, f := range c.getMethodOptions() {
+		f(option)
+	}
+	return option
+}
+
 func (c *Client) DownloadImage(ctx context.Context, imgToken, outDir string) (string, error) {
+	return c.downloadDriveMedia(ctx, imgToken, outDir)
+}
+
+func (c *Client) DownloadImageRaw(ctx context.Context, imgToken, imgDir string) (string, []byte, error) {
+	return c.downloadDriveMediaRaw(ctx, imgToken, imgDir)
+}
+
+// DownloadAttachment 下载文档中的附件（文件 Block），与图片共用素材下载接口
+func (c *Client) DownloadAttachment(ctx context.Context, fileToken, outDir string) (string, error) {
+	return c.downloadDriveMedia(ctx, fileToken, outDir)
+}
+
+// DownloadAttachmentRaw 下载附件内容到内存，返回其在 attachmentDir 下的路径
+func (c *Client) DownloadAttachmentRaw(ctx context.Context, fileToken, attachmentDir string) (string, []byte, error) {
+	return c.downloadDriveMediaRaw(ctx, fileToken, attachmentDir)
+}
+
+func (c *Client) downloadDriveMedia(ctx context.Context, fileToken, outDir string) (string, error) {
 	resp, _, err := c.larkClient.Drive.DownloadDriveMedia(ctx, &lark.DownloadDriveMediaReq{
-		FileToken: imgToken,
+		FileToken: fileToken,
 	}, c.getMethodOptions()...)
 	if err != nil {
-		return imgToken, err
+		return fileToken, err
 	}
 	fileext := filepath.Ext(resp.Filename)
-	filename := fmt.Sprintf("%s/%s%s", outDir, imgToken, fileext)
+	filename := fmt.Sprintf("%s/%s%s", outDir, fileToken, fileext)
 	err = os.MkdirAll(filepath.Dir(filename), 0o755)
 	if err != nil {
-		return imgToken, err
+		return fileToken, err
 	}
 	file, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY, 0o666)
 	if err != nil {
-		return imgToken, err
+		return fileToken, err
 	}
 	defer file.Close()
 	_, err = io.Copy(file, resp.File)
 	if err != nil {
-		return imgToken, err
+		return fileToken, err
 	}
 	return filename, nil
 }
 
-func (c *Client) DownloadImageRaw(ctx context.Context, imgToken, imgDir string) (string, []byte, error) {
+func (c *Client) downloadDriveMediaRaw(ctx context.Context, fileToken, outDir string) (string, []byte, error) {
 	resp, _, err := c.larkClient.Drive.DownloadDriveMedia(ctx, &lark.DownloadDriveMediaReq{
-		FileToken: imgToken,
+		FileToken: fileToken,
 	}, c.getMethodOptions()...)
 	if err != nil {
-		return imgToken, nil, err
+		return fileToken, nil, err
 	}
 	fileext := filepath.Ext(resp.Filename)
-	filename := fmt.Sprintf("%s/%s%s", imgDir, imgToken, fileext)
+	filename := fmt.Sprintf("%s/%s%s", outDir, fileToken, fileext)
 	buf := new(bytes.Buffer)
 	buf.ReadFrom(resp.File)
 	return filename, buf.Bytes(), nil
 }
 
-func (c *Client) GetDocxContent(ctx context.Context, docToken string) (*lark.DocxDocument, []*lark.DocxBlock, error) {
+// DocxBlockProperty lark.DocxBlock 中缺少的 Block 属性，与 Block 列表在同一次请求中解析
+type DocxBlockProperty struct {
+	HeaderRow bool           // 表格首行是否为标题行
+	Sequence  string         // 有序列表的编号：数字为该项的编号（如 "1" 表示重新开始编号），"auto" 表示接着上一项继续编号
+	Align     lark.DocxAlign // 图片的对齐方式
+	Caption   string         // 图片描述
+}
+
+// DocxBlockProperties 以 Block ID 为键的 Block 属性
+type DocxBlockProperties map[string]*DocxBlockProperty
+
+// getDocxBlockListResp 与 lark.GetDocxBlockListOfDocumentResp 相同，但保留每个 Block 的原始 JSON，
+// 以便同时解析 lark.DocxBlock 中缺少的属性
+type getDocxBlockListResp struct {
+	Code int64  `json:"code,omitempty"`
+	Msg  string `json:"msg,omitempty"`
+	Data *struct {
+		Items     []json.RawMessage `json:"items,omitempty"`
+		HasMore   bool              `json:"has_more,omitempty"`
+		PageToken string            `json:"page_token,omitempty"`
+	} `json:"data,omitempty"`
+}
+
+// docxBlockExtra lark.DocxBlockTableProperty 中没有标题行属性，lark.DocxTextStyle 中没有有序列表的编号属性，
+// lark.DocxBlockImage 中没有对齐方式和图片描述
+type docxBlockExtra struct {
+	Table *struct {
+		Property *struct {
+			HeaderRow bool `json:"header_row,omitempty"`
+		} `json:"property,omitempty"`
+	} `json:"table,omitempty"`
+	Ordered *struct {
+		Style *struct {
+			Sequence string `json:"sequence,omitempty"`
+		} `json:"style,omitempty"`
+	} `json:"ordered,omitempty"`
+	Image *struct {
+		Align   lark.DocxAlign `json:"align,omitempty"`
+		Caption *struct {
+			Content string `json:"content,omitempty"`
+		} `json:"caption,omitempty"`
+	} `json:"image,omitempty"`
+}
+
+// property 返回 Block 的额外属性，没有额外属性的 Block 返回 nil
+func (e *docxBlockExtra) property(b *lark.DocxBlock) *DocxBlockProperty {
+	switch b.BlockType {
+	case lark.DocxBlockTypeTable:
+		return &DocxBlockProperty{HeaderRow: e.Table != nil && e.Table.Property != nil && e.Table.Property.HeaderRow}
+	case lark.DocxBlockTypeOrdered:
+		if e.Ordered != nil && e.Ordered.Style != nil && e.Ordered.Style.Sequence != "" {
+			return &DocxBlockProperty{Sequence: e.Ordered.Style.Sequence}
+		}
+	case lark.DocxBlockTypeImage:
+		if e.Image != nil {
+			property := &DocxBlockProperty{Align: e.Image.Align}
+			if e.Image.Caption != nil {
+				property.Caption = strings.TrimSpace(e.Image.Caption.Content)
+			}
+			return property
+		}
+	}
+	return nil
+}
+
+// GetDocxContent 读取文档及其全部 Block，同时返回 lark.DocxBlock 中缺少的 Block 属性
+func (c *Client) GetDocxContent(ctx context.Context, docToken string) (*lark.DocxDocument, []*lark.DocxBlock, DocxBlockProperties, error) {
 	resp, _, err := c.larkClient.Drive.GetDocxDocument(ctx, &lark.GetDocxDocumentReq{
 		DocumentID: docToken,
 	}, c.getMethodOptions()...)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 	docx := &lark.DocxDocument{
 		DocumentID: resp.Document.DocumentID,
@@ -104,22 +211,48 @@ func (c *Client) GetDocxContent(ctx context.Context, docToken string) (*lark.Doc
 		Title:      resp.Document.Title,
 	}
 	var blocks []*lark.DocxBlock
+	properties := DocxBlockProperties{}
 	var pageToken *string
 	for {
-		resp2, _, err := c.larkClient.Drive.GetDocxBlockListOfDocument(ctx, &lark.GetDocxBlockListOfDocumentReq{
-			DocumentID: docx.DocumentID,
-			PageToken:  pageToken,
-		}, c.getMethodOptions()...)
+		resp2 := new(getDocxBlockListResp)
+		_, err := c.larkClient.RawRequest(ctx, &lark.RawRequestReq{
+			Scope:  "Drive",
+			API:    "GetDocxBlockListOfDocument",
+			Method: "GET",
+			URL:    c.openBaseURL + "/open-apis/docx/v1/documents/:document_id/blocks",
+			Body: &lark.GetDocxBlockListOfDocumentReq{
+				DocumentID: docx.DocumentID,
+				PageToken:  pageToken,
+			},
+			MethodOption:          c.getMethodOption(),
+			NeedTenantAccessToken: true,
+			NeedUserAccessToken:   true,
+		}, resp2)
 		if err != nil {
-			return docx, nil, err
+			return docx, nil, nil, err
+		}
+		if resp2.Data == nil {
+			break
+		}
+		for _, item := range resp2.Data.Items {
+			block, extra := new(lark.DocxBlock), new(docxBlockExtra)
+			if err := json.Unmarshal(item, block); err != nil {
+				return docx, nil, nil, err
+			}
+			if err := json.Unmarshal(item, extra); err != nil {
+				return docx, nil, nil, err
+			}
+			blocks = append(blocks, block)
+			if property := extra.property(block); property != nil {
+				properties[block.BlockID] = property
+			}
 		}
-		blocks = append(blocks, resp2.Items...)
-		pageToken = &resp2.PageToken
-		if !resp2.HasMore {
+		if !resp2.Data.HasMore || resp2.Data.PageToken == "" {
 			break
 		}
+		pageToken = &resp2.Data.PageToken
 	}
-	return docx, blocks, nil
+	return docx, blocks, properties, nil
 }
 
 func (c *Client) GetWikiNodeInfo(ctx context.Context, token string) (*lark.GetWikiNodeRespNode, error) {
@@ -132,6 +265,299 @@ func (c *Client) GetWikiNodeInfo(ctx context.Context, token string) (*lark.GetWi
 	return resp.Node, nil
 }
 
+// SheetData 内嵌电子表格中单个工作表的数据
+type SheetData struct {
+	Values [][]*SheetCell // 单元格内容，按行排列，空单元格可能为 nil
+	Merges []*SheetMerge  // 合并单元格范围
+}
+
+// SheetCell 单元格内容，富文本单元格由多个片段组成
+type SheetCell struct {
+	Segments []*SheetSegment
+}
+
+// SheetSegment 单元格中的一段文本，Link 非空时为超链接
+type SheetSegment struct {
+	Text string
+	Link string
+}
+
+// Text 返回单元格的纯文本
+func (c *SheetCell) Text() string {
+	if c == nil {
+		return ""
+	}
+	buf := new(strings.Builder)
+	for _, seg := range c.Segments {
+		buf.WriteString(seg.Text)
+	}
+	return buf.String()
+}
+
+// SheetMerge 合并单元格范围，下标从 0 开始，包含结束行列
+type SheetMerge struct {
+	StartRow    int64
+	EndRow      int64
+	StartColumn int64
+	EndColumn   int64
+}
+
+// getSheetValueResp 单元格值可能是字符串、数字或富文本片段，
+// lark.SheetContent 无法解析小数，因此这里使用原始结构
+type getSheetValueResp struct {
+	Code int64  `json:"code,omitempty"`
+	Msg  string `json:"msg,omitempty"`
+	Data *struct {
+		ValueRange *struct {
+			Values [][]interface{} `json:"values,omitempty"`
+		} `json:"valueRange,omitempty"`
+	} `json:"data,omitempty"`
+}
+
+// GetSheetData 读取工作表左上角 maxRows 行、maxCols 列范围内的数据及合并单元格信息
+func (c *Client) GetSheetData(ctx context.Context, spreadsheetToken, sheetID string, maxRows, maxCols int) (*SheetData, error) {
+	sheetResp, _, err := c.larkClient.Drive.GetSheet(ctx, &lark.GetSheetReq{
+		SpreadSheetToken: spreadsheetToken,
+		SheetID:          sheetID,
+	}, c.getMethodOptions()...)
+	if err != nil {
+		return nil, err
+	}
+
+	valueRenderOption := "FormattedValue"
+	dateTimeRenderOption := "FormattedString"
+	resp := new(getSheetValueResp)
+	_, err = c.larkClient.RawRequest(ctx, &lark.RawRequestReq{
+		Scope:  "Drive",
+		API:    "GetSheetValue",
+		Method: "GET",
+		URL:    c.openBaseURL + "/open-apis/sheets/v2/spreadsheets/:spreadsheetToken/values/:range",
+		Body: &lark.GetSheetValueReq{
+			SpreadSheetToken:     spreadsheetToken,
+			Range:                fmt.Sprintf("%s!A1:%s%d", sheetID, sheetColumnName(maxCols), maxRows),
+			ValueRenderOption:    &valueRenderOption,
+			DateTimeRenderOption: &dateTimeRenderOption,
+		},
+		MethodOption:          c.getMethodOption(),
+		NeedTenantAccessToken: true,
+		NeedUserAccessToken:   true,
+	}, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &SheetData{}
+	if resp.Data != nil && resp.Data.ValueRange != nil {
+		for _, row := range resp.Data.ValueRange.Values {
+			cells := make([]*SheetCell, len(row))
+			for i, v := range row {
+				cells[i] = &SheetCell{Segments: sheetCellSegments(v)}
+			}
+			data.Values = append(data.Values, cells)
+		}
+	}
+	if sheetResp.Sheet != nil {
+		for _, m := range sheetResp.Sheet.Merges {
+			data.Merges = append(data.Merges, &SheetMerge{
+				StartRow:    m.StartRowIndex,
+				EndRow:      m.EndRowIndex,
+				StartColumn: m.StartColumnIndex,
+				EndColumn:   m.EndColumnIndex,
+			})
+		}
+	}
+	return data, nil
+}
+
+// BitableData 多维表格中单个数据表的字段与记录
+type BitableData struct {
+	Fields  []*BitableField          // 字段，按数据表中的顺序排列
+	Records []map[string]interface{} // 记录，字段名 -> 原始值
+}
+
+// BitableField 多维表格字段
+type BitableField struct {
+	Name string
+	Type int64 // 字段类型，如 1：多行文本，4：多选，5：日期，11：人员，15：超链接，18：单向关联
+}
+
+// GetBitableData 分页读取多维表格数据表的全部字段和记录，
+// 单向关联和双向关联字段的值替换为被关联记录的索引字段（数据表的第一个字段）的文本
+func (c *Client) GetBitableData(ctx context.Context, appToken, tableID string) (*BitableData, error) {
+	fields, err := c.getBitableFields(ctx, appToken, tableID)
+	if err != nil {
+		return nil, err
+	}
+	records, err := c.getBitableRecords(ctx, appToken, tableID)
+	if err != nil {
+		return nil, err
+	}
+	data := &BitableData{}
+	for _, record := range records {
+		data.Records = append(data.Records, record.Fields)
+	}
+
+	names := map[string]map[string]string{} // 被关联的数据表 -> 记录 ID -> 索引字段的文本
+	for _, field := range fields {
+		data.Fields = append(data.Fields, &BitableField{Name: field.FieldName, Type: field.Type})
+		if field.Type != bitableFieldTypeLink && field.Type != bitableFieldTypeDuplexLink {
+			continue
+		}
+		if field.Property == nil || field.Property.TableID == "" {
+			continue
+		}
+		linkedTable := field.Property.TableID
+		if _, ok := names[linkedTable]; !ok {
+			if names[linkedTable], err = c.getBitableRecordNames(ctx, appToken, linkedTable); err != nil {
+				return nil, err
+			}
+		}
+		for _, record := range data.Records {
+			if v, ok := record[field.FieldName]; ok {
+				record[field.FieldName] = linkedRecordNames(v, names[linkedTable])
+			}
+		}
+	}
+	return data, nil
+}
+
+// getBitableFields 分页读取数据表的全部字段
+func (c *Client) getBitableFields(ctx context.Context, appToken, tableID string) ([]*lark.GetBitableFieldListRespItem, error) {
+	var fields []*lark.GetBitableFieldListRespItem
+	var pageToken *string
+	for {
+		resp, _, err := c.larkClient.Bitable.GetBitableFieldList(ctx, &lark.GetBitableFieldListReq{
+			AppToken:  appToken,
+			TableID:   tableID,
+			PageToken: pageToken,
+		}, c.getMethodOptions()...)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, resp.Items...)
+		if !resp.HasMore || resp.PageToken == "" {
+			return fields, nil
+		}
+		pageToken = &resp.PageToken
+	}
+}
+
+// getBitableRecords 分页读取数据表的全部记录
+func (c *Client) getBitableRecords(ctx context.Context, appToken, tableID string) ([]*lark.GetBitableRecordListRespItem, error) {
+	var records []*lark.GetBitableRecordListRespItem
+	pageSize := int64(500)
+	var pageToken *string
+	for {
+		resp, _, err := c.larkClient.Bitable.GetBitableRecordList(ctx, &lark.GetBitableRecordListReq{
+			AppToken:  appToken,
+			TableID:   tableID,
+			PageToken: pageToken,
+			PageSize:  &pageSize,
+		}, c.getMethodOptions()...)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, resp.Items...)
+		if !resp.HasMore || resp.PageToken == "" {
+			return records, nil
+		}
+		pageToken = &resp.PageToken
+	}
+}
+
+// getBitableRecordNames 返回数据表中各记录的 ID 到索引字段文本的映射
+func (c *Client) getBitableRecordNames(ctx context.Context, appToken, tableID string) (map[string]string, error) {
+	fields, err := c.getBitableFields(ctx, appToken, tableID)
+	if err != nil || len(fields) == 0 {
+		return nil, err
+	}
+	records, err := c.getBitableRecords(ctx, appToken, tableID)
+	if err != nil {
+		return nil, err
+	}
+	primary := fields[0]
+	names := make(map[string]string, len(records))
+	for _, record := range records {
+		names[record.RecordID] = PlainText(bitableCellInlines(primary.Type, record.Fields[primary.FieldName]))
+	}
+	return names, nil
+}
+
+// linkedRecordNames 将关联字段的值 {"link_record_ids": [...]} 替换为被关联记录的名称，
+// 找不到的记录保留 ID，其他格式的值（如已包含 text 的新版格式）保持不变
+func linkedRecordNames(v interface{}, names map[string]string) interface{} {
+	val, ok := v.(map[string]interface{})
+	if !ok {
+		return v
+	}
+	ids, ok := val["link_record_ids"].([]interface{})
+	if !ok {
+		return v
+	}
+	result := make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		recordID, _ := id.(string)
+		if name := names[recordID]; name != "" {
+			result = append(result, name)
+		} else {
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+// sheetColumnName 将从 1 开始的列号转换为 A、B、...、AA 形式的列名
+func sheetColumnName(n int) string {
+	name := ""
+	for n > 0 {
+		n--
+		name = string(rune('A'+n%26)) + name
+		n /= 26
+	}
+	return name
+}
+
+// sheetCellSegments 将单元格的值转换为文本片段，超链接保留链接地址
+func sheetCellSegments(v interface{}) []*SheetSegment {
+	switch val := v.(type) {
+	case nil:
+		return nil
+	case string:
+		return []*SheetSegment{{Text: val}}
+	case float64:
+		return []*SheetSegment{{Text: strconv.FormatFloat(val, 'f', -1, 64)}}
+	case bool:
+		return []*SheetSegment{{Text: strconv.FormatBool(val)}}
+	case []interface{}:
+		var segments []*SheetSegment
+		for _, seg := range val {
+			segments = append(segments, sheetCellSegments(seg)...)
+		}
+		return segments
+	case map[string]interface{}:
+		text, _ := val["text"].(string)
+		if link, ok := val["link"].(string); ok && link != "" {
+			if text == "" {
+				text = link
+			}
+			return []*SheetSegment{{Text: text, Link: link}}
+		}
+		if values, ok := val["values"].([]interface{}); ok {
+			var segments []*SheetSegment
+			for i, item := range values {
+				if i > 0 {
+					segments = append(segments, &SheetSegment{Text: ", "})
+				}
+				segments = append(segments, sheetCellSegments(item)...)
+			}
+			return segments
+		}
+		return []*SheetSegment{{Text: text}}
+	default:
+		return []*SheetSegment{{Text: fmt.Sprint(val)}}
+	}
+}
+
 func (c *Client) GetDriveFolderFileList(ctx context.Context, pageToken *string, folderToken *string) ([]*lark.GetDriveFileListRespFile, error) {
 	resp, _, err := c.larkClient.Drive.GetDriveFileList(ctx, &lark.GetDriveFileListReq{
 		PageSize:    nil,
@@ -61,7 +61,7 @@ func TestDownloadImage(t *testing.T) {
 func TestGetDocxContent(t *testing.T) {
 	config := getConfigFromEnv(t)
 	c := core.NewClient(config)
-	docx, blocks, err := c.GetDocxContent(
+	docx, blocks, _, err := c.GetDocxContent(
 		context.Background(),
 		"doxcnXhd93zqoLnmVPGIPTy7AFe",
 	)
@@ -0,0 +1,67 @@
+package core
+
+import (
+	"fmt"
+)
+
+// DefaultTextColors 飞书字体颜色枚举对应的默认色值
+var DefaultTextColors = map[int]string{
+	1: "#d83931", // 红色
+	2: "#de7802", // 橙色
+	3: "#dc9b04", // 黄色
+	4: "#2ea121", // 绿色
+	5: "#245bdb", // 蓝色
+	6: "#6425d0", // 紫色
+	7: "#8f959e", // 灰色
+}
+
+// DefaultBackgroundColors 飞书背景色枚举对应的默认色值
+var DefaultBackgroundColors = map[int]string{
+	1:  "#fbbfbc", // 浅红色
+	2:  "#fed4a4", // 浅橙色
+	3:  "#fff67a", // 浅黄色
+	4:  "#b7edb1", // 浅绿色
+	5:  "#bacefd", // 浅蓝色
+	6:  "#cdb2fa", // 浅紫色
+	7:  "#eff0f1", // 浅灰色
+	8:  "#f76964", // 暗红色
+	9:  "#ffa53d", // 暗橙色
+	10: "#ffe928", // 暗黄色
+	11: "#62d256", // 暗绿色
+	12: "#4e83fd", // 暗蓝色
+	13: "#935af6", // 暗紫色
+	14: "#dee0e3", // 暗灰色
+	15: "#bbbfc4", // 暗银灰色
+}
+
+// mergeDefaults 以默认映射为基础，使用配置中的映射覆盖
+func mergeDefaults[K comparable, V any](defaults, overrides map[K]V) map[K]V {
+	merged := make(map[K]V, len(defaults)+len(overrides))
+	for k, v := range defaults {
+		merged[k] = v
+	}
+	for k, v := range overrides {
+		merged[k] = v
+	}
+	return merged
+}
+
+// renderColor 按颜色输出方式为文本加上字体颜色和背景高亮
+func (e *MarkdownEmitter) renderColor(content string, i *Inline) string {
+	if e.colorMode != ColorModeHTML && e.colorMode != ColorModeObsidian {
+		return content
+	}
+	if color, ok := i.Attrs["color"]; ok {
+		content = fmt.Sprintf(`<span style="color:%s">%s</span>`, color, content)
+	}
+	if !i.HasMark(MarkHighlight) {
+		return content
+	}
+	if e.colorMode == ColorModeObsidian {
+		return "==" + content + "=="
+	}
+	if color, ok := i.Attrs["background_color"]; ok {
+		return fmt.Sprintf(`<mark style="background-color:%s">%s</mark>`, color, content)
+	}
+	return "<mark>" + content + "</mark>"
+}
@@ -6,6 +6,9 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
 )
 
 // 鉴权类型常量
@@ -14,6 +17,72 @@ const (
 	AuthTypeUser = "user"
 )
 
+// 多维表格输出方式
+const (
+	BitableModeInline = "inline" // 在 Markdown 中内联为表格
+	BitableModeCSV    = "csv"    // 导出为与 Markdown 同目录的 CSV 文件
+)
+
+// 表格输出方式
+const (
+	TableModeHTML = "html" // 始终输出为 HTML 表格，为空时的默认值
+	TableModeGFM  = "gfm"  // 始终输出为 GFM 表格，合并单元格会被拆开
+	TableModeAuto = "auto" // 没有合并单元格时输出为 GFM 表格，否则输出为 HTML 表格
+)
+
+// @提及用户的输出方式
+const (
+	MentionModeName     = "name"     // 输出为 @姓名
+	MentionModeMailto   = "mailto"   // 输出为指向用户邮箱的 mailto: 链接
+	MentionModeTemplate = "template" // 使用 mention_template 自定义输出
+)
+
+// front matter 格式
+const (
+	FrontMatterNone = "none"
+	FrontMatterYAML = "yaml"
+	FrontMatterTOML = "toml"
+)
+
+// 文字颜色输出方式
+const (
+	ColorModeNone     = "none"     // 忽略字体颜色和背景色
+	ColorModeHTML     = "html"     // 输出为 <span style="color:…"> 和 <mark>
+	ColorModeObsidian = "obsidian" // 背景色输出为 ==高亮==
+)
+
+// Markdown 方言
+const (
+	FlavorGFM      = "gfm"      // GitHub 风格的 Markdown
+	FlavorObsidian = "obsidian" // Obsidian 风格，图片和文档链接输出为 [[wikilink]]，callout 类型使用小写
+)
+
+// 文档输出格式
+const (
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+	FormatJSON     = "json" // 文档的 AST，见 Document
+)
+
+// Markdown 中图片的输出方式
+const (
+	ImageModeMarkdown = "markdown" // 标准 Markdown 图片，图片描述作为替代文本和标题
+	ImageModeHTML     = "html"     // 带尺寸和对齐方式的 <img> 标签
+)
+
+// 图片目录的组织方式
+const (
+	ImageLayoutDocument    = "document"     // 每个文档所在目录下的 image_dir
+	ImageLayoutShared      = "shared"       // 所有文档共用下载根目录下的 image_dir
+	ImageLayoutPerDocument = "per_document" // 每个文档单独的 <文档名>.assets 目录，与文档位于同一目录
+)
+
+// HTML 输出中图片的引用方式
+const (
+	HTMLImagesLink    = "link"     // 链接到 image_dir 中下载的图片
+	HTMLImagesDataURI = "data_uri" // 以 data URI 内嵌，生成单文件 HTML
+)
+
 // 配置版本
 const ConfigVersion = "2.0"
 
@@ -33,13 +102,48 @@ type FeishuConfig struct {
 	// 鉴权类型选择: "app" 或 "user"
 	// 默认为 "app",保持向后兼容
 	AuthType string `json:"auth_type,omitempty"`
+
+	// 开放平台地址，默认为 https://open.feishu.cn，Lark 国际版为 https://open.larksuite.com
+	OpenBaseURL string `json:"open_base_url,omitempty"`
 }
 
 type OutputConfig struct {
 	ImageDir        string `json:"image_dir"`
+	AttachmentDir   string `json:"attachment_dir"`
 	TitleAsFilename bool   `json:"title_as_filename"`
 	UseHTMLTags     bool   `json:"use_html_tags"`
 	SkipImgDownload bool   `json:"skip_img_download"`
+	SheetMaxRows    int    `json:"sheet_max_rows"`
+	SheetMaxCols    int    `json:"sheet_max_cols"`
+	BitableMode     string `json:"bitable_mode"`
+	TableMode       string `json:"table_mode"`
+	MentionMode     string `json:"mention_mode"`
+	FrontMatter     string `json:"front_matter"`
+	Preset          string `json:"preset,omitempty"`
+	// 额外写入 front matter 的静态键值，会覆盖同名的默认字段
+	FrontMatterFields map[string]interface{} `json:"front_matter_fields,omitempty"`
+	// text/template 模板，可使用 .Name、.EnName、.Email 和 .OpenID
+	MentionTemplate string `json:"mention_template,omitempty"`
+	ColorMode       string `json:"color_mode"`
+	Flavor          string `json:"flavor"`
+	// HTML 输出的页面模板文件，为空时使用内置模板
+	HTMLTemplate string `json:"html_template,omitempty"`
+	HTMLImages   string `json:"html_images"`
+	ImageMode    string `json:"image_mode"`
+	ImageLayout  string `json:"image_layout"`
+	ImageNaming  string `json:"image_naming"`
+	// 在未支持的 Block 处输出 HTML 注释占位，便于发现被丢弃的内容
+	UnsupportedPlaceholder bool `json:"unsupported_placeholder"`
+	// 在标题后插入由文档各级标题生成的目录
+	TOC bool `json:"toc"`
+	// 在标题后输出 {#锚点} 形式的显式锚点
+	HeadingAnchors bool `json:"heading_anchors"`
+	// 飞书颜色枚举到色值的映射，未配置的颜色使用默认色值
+	TextColors       map[int]string `json:"text_colors,omitempty"`
+	BackgroundColors map[int]string `json:"background_colors,omitempty"`
+	// 高亮块 emoji、背景色到 admonition 类型的映射，优先按 emoji 匹配
+	CalloutEmojiTypes map[string]string `json:"callout_emoji_types,omitempty"`
+	CalloutColorTypes map[int]string    `json:"callout_color_types,omitempty"`
 }
 
 func NewConfig(appId, appSecret string) *Config {
@@ -52,13 +156,37 @@ func NewConfig(appId, appSecret string) *Config {
 		},
 		Output: OutputConfig{
 			ImageDir:        "static",
+			AttachmentDir:   "attachments",
 			TitleAsFilename: false,
 			UseHTMLTags:     false,
 			SkipImgDownload: false,
+			SheetMaxRows:    DefaultSheetMaxRows,
+			SheetMaxCols:    DefaultSheetMaxCols,
+			BitableMode:     BitableModeInline,
+			TableMode:       TableModeHTML,
+			MentionMode:     MentionModeName,
+			FrontMatter:     FrontMatterNone,
+			ColorMode:       ColorModeNone,
+			Flavor:          FlavorGFM,
+			HTMLImages:      HTMLImagesLink,
+			ImageMode:       ImageModeMarkdown,
+			ImageLayout:     ImageLayoutDocument,
+			ImageNaming:     ImageNamingToken,
 		},
 	}
 }
 
+// DocImageDir 按 image_layout 返回文档的图片目录，rootDir 为本次下载的根目录，docPath 为文档的输出路径
+func (o *OutputConfig) DocImageDir(rootDir, docPath string) string {
+	switch o.ImageLayout {
+	case ImageLayoutShared:
+		return filepath.Join(rootDir, o.ImageDir)
+	case ImageLayoutPerDocument:
+		return strings.TrimSuffix(docPath, filepath.Ext(docPath)) + ".assets"
+	}
+	return filepath.Join(filepath.Dir(docPath), o.ImageDir)
+}
+
 // Validate 验证配置的有效性
 func (fc *FeishuConfig) Validate() error {
 	// 设置默认值
@@ -85,6 +213,36 @@ func (fc *FeishuConfig) Validate() error {
 	return nil
 }
 
+// Validate 验证输出配置的有效性
+func (o *OutputConfig) Validate() error {
+	// 枚举值为空时使用默认值
+	enums := []struct {
+		name    string
+		value   string
+		allowed []string
+	}{
+		{"bitable_mode", o.BitableMode, []string{BitableModeInline, BitableModeCSV}},
+		{"table_mode", o.TableMode, []string{TableModeHTML, TableModeGFM, TableModeAuto}},
+		{"mention_mode", o.MentionMode, []string{MentionModeName, MentionModeMailto, MentionModeTemplate}},
+		{"front_matter", o.FrontMatter, []string{FrontMatterNone, FrontMatterYAML, FrontMatterTOML}},
+		{"color_mode", o.ColorMode, []string{ColorModeNone, ColorModeHTML, ColorModeObsidian}},
+		{"image_layout", o.ImageLayout, []string{ImageLayoutDocument, ImageLayoutShared, ImageLayoutPerDocument}},
+		{"image_naming", o.ImageNaming, []string{ImageNamingToken, ImageNamingSHA256}},
+	}
+	for _, e := range enums {
+		if e.value != "" && !slices.Contains(e.allowed, e.value) {
+			return fmt.Errorf("invalid %s: %s, must be one of %s", e.name, e.value, strings.Join(e.allowed, ", "))
+		}
+	}
+
+	if o.MentionMode == MentionModeTemplate && o.MentionTemplate != "" {
+		if _, err := template.New("mention").Parse(o.MentionTemplate); err != nil {
+			return fmt.Errorf("invalid mention_template: %w", err)
+		}
+	}
+	return nil
+}
+
 func GetConfigFilePath() (string, error) {
 	configPath, err := os.UserConfigDir()
 	if err != nil {
@@ -112,6 +270,9 @@ func ReadConfigFromFile(configPath string) (*Config, error) {
 	if err = config.Feishu.Validate(); err != nil {
 		return nil, err
 	}
+	if err = config.Output.Validate(); err != nil {
+		return nil, err
+	}
 
 	// 如果发生迁移，自动保存新配置
 	if migrated {
@@ -111,6 +111,54 @@ func TestConfigReadWrite(t *testing.T) {
 	assert.Equal(t, AuthTypeUser, readConfig2.Feishu.AuthType)
 }
 
+func TestOutputConfigValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(o *OutputConfig)
+		errMsg string
+	}{
+		{"默认配置", func(o *OutputConfig) {}, ""},
+		{"枚举值为空", func(o *OutputConfig) { o.TableMode, o.ImageLayout = "", "" }, ""},
+		{"无效的 bitable_mode", func(o *OutputConfig) { o.BitableMode = "xlsx" }, "invalid bitable_mode: xlsx"},
+		{"无效的 table_mode", func(o *OutputConfig) { o.TableMode = "markdown" }, "invalid table_mode: markdown"},
+		{"无效的 color_mode", func(o *OutputConfig) { o.ColorMode = "htm" }, "invalid color_mode: htm"},
+		{"无效的 image_layout", func(o *OutputConfig) { o.ImageLayout = "flat" }, "invalid image_layout: flat"},
+		{"无效的 image_naming", func(o *OutputConfig) { o.ImageNaming = "md5" }, "invalid image_naming: md5"},
+		{"无效的 front_matter", func(o *OutputConfig) { o.FrontMatter = "yml" }, "invalid front_matter: yml, must be one of none, yaml, toml"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := NewConfig("", "").Output
+			tt.modify(&output)
+			err := output.Validate()
+			if tt.errMsg == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestReadConfigInvalidMentionTemplate(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+
+	config := NewConfig("test_app_id", "test_app_secret")
+	config.Output.MentionMode = MentionModeTemplate
+	config.Output.MentionTemplate = "@{{.Name"
+	assert.NoError(t, config.WriteConfig2File(configPath))
+
+	_, err := ReadConfigFromFile(configPath)
+	assert.ErrorContains(t, err, "invalid mention_template")
+
+	// 未使用模板输出时不检查模板
+	config.Output.MentionMode = MentionModeName
+	assert.NoError(t, config.WriteConfig2File(configPath))
+	_, err = ReadConfigFromFile(configPath)
+	assert.NoError(t, err)
+}
+
 func TestConfigMigrate(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -205,3 +253,21 @@ func TestNewConfigHasVersion(t *testing.T) {
 	assert.Equal(t, ConfigVersion, config.Version)
 	assert.Equal(t, AuthTypeApp, config.Feishu.AuthType)
 }
+
+func TestDocImageDir(t *testing.T) {
+	tests := []struct {
+		layout   string
+		expected string
+	}{
+		{"", filepath.Join("out", "guide", "static", "images")},
+		{ImageLayoutDocument, filepath.Join("out", "guide", "static", "images")},
+		{ImageLayoutShared, filepath.Join("out", "static", "images")},
+		{ImageLayoutPerDocument, filepath.Join("out", "guide", "intro.assets")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.layout, func(t *testing.T) {
+			output := OutputConfig{ImageDir: "static/images", ImageLayout: tt.layout}
+			assert.Equal(t, tt.expected, output.DocImageDir("out", filepath.Join("out", "guide", "intro.md")))
+		})
+	}
+}
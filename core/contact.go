@@ -0,0 +1,124 @@
+package core
+
+import (
+	"context"
+	"time"
+
+	"github.com/chyroc/lark"
+)
+
+// 通讯录批量获取用户接口单次最多查询的用户数
+const batchGetUsersLimit = 50
+
+// 用户信息缓存的有效期，过期后重新查询以获取最新的姓名
+const userCacheTTL = 7 * 24 * time.Hour
+
+// UserInfo 通讯录中的用户信息
+type UserInfo struct {
+	Name   string `json:"name"`
+	EnName string `json:"en_name,omitempty"`
+	Email  string `json:"email,omitempty"`
+}
+
+// UserResolver 根据 open_id 批量查询用户信息，*Client 实现了该接口
+type UserResolver interface {
+	BatchGetUsers(ctx context.Context, openIDs []string) (map[string]*UserInfo, error)
+}
+
+// batchGetUsersResp 当前依赖的 lark 版本没有通讯录 v3 的批量获取用户接口
+type batchGetUsersResp struct {
+	Code int64  `json:"code,omitempty"`
+	Msg  string `json:"msg,omitempty"`
+	Data *struct {
+		Items []*struct {
+			OpenID          string `json:"open_id,omitempty"`
+			Name            string `json:"name,omitempty"`
+			EnName          string `json:"en_name,omitempty"`
+			Email           string `json:"email,omitempty"`
+			EnterpriseEmail string `json:"enterprise_email,omitempty"`
+		} `json:"items,omitempty"`
+	} `json:"data,omitempty"`
+}
+
+// BatchGetUsers 通过通讯录接口批量查询用户信息，返回 open_id -> 用户信息，没有权限查询的用户不会出现在结果中
+func (c *Client) BatchGetUsers(ctx context.Context, openIDs []string) (map[string]*UserInfo, error) {
+	users := map[string]*UserInfo{}
+	for start := 0; start < len(openIDs); start += batchGetUsersLimit {
+		end := min(start+batchGetUsersLimit, len(openIDs))
+		resp := new(batchGetUsersResp)
+		_, err := c.larkClient.RawRequest(ctx, &lark.RawRequestReq{
+			Scope:  "Contact",
+			API:    "BatchGetUsers",
+			Method: "GET",
+			URL:    c.openBaseURL + "/open-apis/contact/v3/users/batch",
+			Body: &struct {
+				UserIDs    []string `query:"user_ids" json:"-"`
+				UserIDType string   `query:"user_id_type" json:"-"`
+			}{
+				UserIDs:    openIDs[start:end],
+				UserIDType: "open_id",
+			},
+			MethodOption:          c.getMethodOption(),
+			NeedTenantAccessToken: true,
+			NeedUserAccessToken:   true,
+		}, resp)
+		if err != nil {
+			return nil, err
+		}
+		if resp.Data == nil {
+			continue
+		}
+		for _, item := range resp.Data.Items {
+			email := item.Email
+			if email == "" {
+				email = item.EnterpriseEmail
+			}
+			users[item.OpenID] = &UserInfo{
+				Name:   item.Name,
+				EnName: item.EnName,
+				Email:  email,
+			}
+		}
+	}
+	return users, nil
+}
+
+// cachedUserResolver 优先从缓存读取用户信息，只查询缺失或过期的用户
+type cachedUserResolver struct {
+	resolver UserResolver
+	cache    *CacheManager
+}
+
+// NewCachedUserResolver 创建使用 CacheManager 跨多次运行缓存用户信息的 UserResolver
+func NewCachedUserResolver(resolver UserResolver, cache *CacheManager) UserResolver {
+	if cache == nil {
+		return resolver
+	}
+	return &cachedUserResolver{resolver: resolver, cache: cache}
+}
+
+func (r *cachedUserResolver) BatchGetUsers(ctx context.Context, openIDs []string) (map[string]*UserInfo, error) {
+	users := map[string]*UserInfo{}
+	var missing []string
+	for _, openID := range openIDs {
+		if user, ok := r.cache.GetUser(openID); ok {
+			users[openID] = user
+		} else {
+			missing = append(missing, openID)
+		}
+	}
+	if len(missing) == 0 {
+		return users, nil
+	}
+
+	fetched, err := r.resolver.BatchGetUsers(ctx, missing)
+	if err != nil {
+		// 查询失败时仍返回缓存中已有的用户
+		return users, err
+	}
+	for openID, user := range fetched {
+		r.cache.UpdateUser(openID, user)
+		users[openID] = user
+	}
+	return users, nil
+}
@@ -0,0 +1,71 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/chyroc/lark"
+	"github.com/stretchr/testify/assert"
+)
+
+func mockBatchGetUsers(c *Client, calls *[][]string) {
+	c.larkClient.Mock().MockRawRequest(func(ctx context.Context, req *lark.RawRequestReq, resp interface{}) (*lark.Response, error) {
+		body := req.Body.(*struct {
+			UserIDs    []string `query:"user_ids" json:"-"`
+			UserIDType string   `query:"user_id_type" json:"-"`
+		})
+		*calls = append(*calls, body.UserIDs)
+		var items []string
+		for _, openID := range body.UserIDs {
+			items = append(items, fmt.Sprintf(
+				`{"open_id":"%s","name":"name_%s","enterprise_email":"%s@example.com"}`, openID, openID, openID))
+		}
+		return nil, json.Unmarshal([]byte(`{"data":{"items":[`+strings.Join(items, ",")+`]}}`), resp)
+	})
+}
+
+func TestBatchGetUsers(t *testing.T) {
+	c := NewClient(FeishuConfig{AppId: "app_id", AppSecret: "app_secret"})
+	var calls [][]string
+	mockBatchGetUsers(c, &calls)
+
+	openIDs := make([]string, 0, 60)
+	for i := 0; i < 60; i++ {
+		openIDs = append(openIDs, fmt.Sprintf("ou_%d", i))
+	}
+	users, err := c.BatchGetUsers(context.Background(), openIDs)
+	assert.NoError(t, err)
+	assert.Len(t, users, 60)
+	assert.Equal(t, &UserInfo{Name: "name_ou_7", Email: "ou_7@example.com"}, users["ou_7"])
+	// 每次最多查询 50 个用户
+	assert.Len(t, calls, 2)
+	assert.Len(t, calls[0], 50)
+	assert.Len(t, calls[1], 10)
+}
+
+func TestCachedUserResolver(t *testing.T) {
+	c := NewClient(FeishuConfig{AppId: "app_id", AppSecret: "app_secret"})
+	var calls [][]string
+	mockBatchGetUsers(c, &calls)
+
+	outputDir := t.TempDir()
+	cache, err := NewCacheManager(outputDir)
+	assert.NoError(t, err)
+	resolver := NewCachedUserResolver(c, cache)
+
+	_, err = resolver.BatchGetUsers(context.Background(), []string{"ou_a"})
+	assert.NoError(t, err)
+	assert.NoError(t, cache.Save())
+
+	// 重新加载缓存后只查询新出现的用户
+	cache, err = NewCacheManager(outputDir)
+	assert.NoError(t, err)
+	users, err := NewCachedUserResolver(c, cache).BatchGetUsers(context.Background(), []string{"ou_a", "ou_b"})
+	assert.NoError(t, err)
+	assert.Equal(t, "name_ou_a", users["ou_a"].Name)
+	assert.Equal(t, "name_ou_b", users["ou_b"].Name)
+	assert.Equal(t, [][]string{{"ou_a"}, {"ou_b"}}, calls)
+}
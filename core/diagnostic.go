@@ -0,0 +1,136 @@
+package core
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/chyroc/lark"
+)
+
+// docxBlockTypeNames 常见的未支持或可能导出失败的 Block 类型的名称，用于输出可读的诊断信息
+var docxBlockTypeNames = map[lark.DocxBlockType]string{
+	lark.DocxBlockTypeChatCard:  "chat_card",
+	lark.DocxBlockTypeIframe:    "iframe",
+	lark.DocxBlockTypeISV:       "isv",
+	lark.DocxBlockTypeMindnote:  "mindnote",
+	lark.DocxBlockTypeUndefined: "undefined",
+	lark.DocxBlockTypeDiagram:   "diagram",
+	DocxBlockTypeBoard:          "board",
+	lark.DocxBlockTypeSheet:     "sheet",
+	lark.DocxBlockTypeBitable:   "bitable",
+}
+
+// Diagnostic 记录一个因不支持或导出失败而未能完整输出的 Block
+type Diagnostic struct {
+	BlockType lark.DocxBlockType `json:"block_type"`
+	BlockID   string             `json:"block_id"`
+	// 从文档开头到该 Block 所在位置的各级标题
+	HeadingPath []string `json:"heading_path,omitempty"`
+	// 导出失败的原因，为空表示该类型的 Block 不受支持
+	Reason string `json:"reason,omitempty"`
+}
+
+// TypeName 返回 Block 类型的名称和编号，如 "isv (28)"
+func (d *Diagnostic) TypeName() string {
+	if name, ok := docxBlockTypeNames[d.BlockType]; ok {
+		return fmt.Sprintf("%s (%d)", name, d.BlockType)
+	}
+	return fmt.Sprintf("type %d", d.BlockType)
+}
+
+func (d *Diagnostic) String() string {
+	s := fmt.Sprintf("unsupported block %s %s", d.TypeName(), d.BlockID)
+	if d.Reason != "" {
+		s = fmt.Sprintf("block %s %s: %s", d.TypeName(), d.BlockID, d.Reason)
+	}
+	if len(d.HeadingPath) > 0 {
+		s += " under " + strings.Join(d.HeadingPath, " > ")
+	}
+	return s
+}
+
+// UnsupportedPlaceholder 返回标记未支持 Block 位置的 HTML 注释，Markdown 和 HTML 中均不可见
+func UnsupportedPlaceholder(blockType lark.DocxBlockType, blockID string) string {
+	d := &Diagnostic{BlockType: blockType, BlockID: blockID}
+	return fmt.Sprintf("<!-- feishu2md: unsupported block %s %s -->", d.TypeName(), blockID)
+}
+
+// unsupportedNodePlaceholder 返回 AST 中未支持节点的占位注释
+func unsupportedNodePlaceholder(b *Block) string {
+	blockType, _ := strconv.Atoi(b.Attrs["block_type"])
+	return UnsupportedPlaceholder(lark.DocxBlockType(blockType), b.ID)
+}
+
+// UnsupportedBlockRenderer 将 Block 记录到 Parser.Diagnostics 并构建为 unsupported 节点，
+// 开启 unsupported_placeholder 时输出为 HTML 注释占位，是未注册类型的默认处理方式
+var UnsupportedBlockRenderer BlockRenderer = BlockRendererFunc(func(p *Parser, b *lark.DocxBlock) []*Block {
+	p.reportUnsupported(b)
+	return []*Block{{
+		Type:  NodeUnsupported,
+		ID:    b.BlockID,
+		Attrs: map[string]string{"block_type": strconv.Itoa(int(b.BlockType))},
+	}}
+})
+
+// reportUnsupported 记录未支持的 Block，同一个 Block 只记录一次
+func (p *Parser) reportUnsupported(b *lark.DocxBlock) {
+	p.reportFailed(b, "")
+}
+
+// reportFailed 记录导出失败的 Block 及原因，同一个 Block 只记录一次
+func (p *Parser) reportFailed(b *lark.DocxBlock, reason string) {
+	for _, d := range p.Diagnostics {
+		if d.BlockID == b.BlockID {
+			return
+		}
+	}
+	p.Diagnostics = append(p.Diagnostics, &Diagnostic{
+		BlockType:   b.BlockType,
+		BlockID:     b.BlockID,
+		HeadingPath: p.headingPath(b.BlockID),
+		Reason:      reason,
+	})
+}
+
+// headingPath 按文档顺序遍历 Block，返回 blockID 之前尚未被同级或更高级标题结束的各级标题
+func (p *Parser) headingPath(blockID string) []string {
+	type heading struct {
+		level int
+		text  string
+	}
+	var stack []heading
+	var walk func(id string) bool
+	walk = func(id string) bool {
+		if id == blockID {
+			return true
+		}
+		b := p.blockMap[id]
+		if b == nil {
+			return false
+		}
+		if b.BlockType >= lark.DocxBlockTypeHeading1 && b.BlockType <= lark.DocxBlockTypeHeading9 {
+			level := int(b.BlockType-lark.DocxBlockTypeHeading1) + 1
+			for len(stack) > 0 && stack[len(stack)-1].level >= level {
+				stack = stack[:len(stack)-1]
+			}
+			text := reflect.ValueOf(b).Elem().FieldByName(fmt.Sprintf("Heading%d", level))
+			stack = append(stack, heading{level, strings.TrimSpace(PlainText(p.buildInlines(text.Interface().(*lark.DocxBlockText))))})
+		}
+		for _, child := range b.Children {
+			if walk(child) {
+				return true
+			}
+		}
+		return false
+	}
+	if !walk(p.documentID) {
+		return nil
+	}
+	var path []string
+	for _, h := range stack {
+		path = append(path, h.text)
+	}
+	return path
+}
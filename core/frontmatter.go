@@ -0,0 +1,227 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chyroc/lark"
+)
+
+// DocumentMeta 云盘中记录的文档元数据
+type DocumentMeta struct {
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	OwnerID   string // 所有者的 open_id
+}
+
+// GetDocumentMeta 获取文档的创建时间、最后编辑时间和所有者
+func (c *Client) GetDocumentMeta(ctx context.Context, docToken, docType string) (*DocumentMeta, error) {
+	resp, _, err := c.larkClient.Drive.GetDriveFileMeta(ctx, &lark.GetDriveFileMetaReq{
+		RequestDocs: []*lark.GetDriveFileMetaReqRequestDocs{
+			{DocToken: docToken, DocType: docType},
+		},
+	}, c.getMethodOptions()...)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Metas) == 0 {
+		if len(resp.FailedList) > 0 {
+			return nil, fmt.Errorf("failed to get meta of %s, code: %d", docToken, resp.FailedList[0].Code)
+		}
+		return nil, fmt.Errorf("failed to get meta of %s", docToken)
+	}
+	meta := resp.Metas[0]
+	return &DocumentMeta{
+		CreatedAt: parseUnixTime(meta.CreateTime),
+		UpdatedAt: parseUnixTime(meta.LatestModifyTime),
+		OwnerID:   meta.OwnerID,
+	}, nil
+}
+
+func parseUnixTime(s string) time.Time {
+	sec, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || sec <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(sec, 0)
+}
+
+// DocumentInfo 写入 front matter 的文档信息
+type DocumentInfo struct {
+	Title      string
+	Token      string
+	RevisionID int64
+	SourceURL  string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	Owner      string
+	Position   int // 在知识库同级节点中的位置，从 1 开始，未知时为 0
+}
+
+// FrontMatterField front matter 中的一个键值对，按顺序输出
+type FrontMatterField struct {
+	Key   string
+	Value interface{}
+}
+
+// DefaultFrontMatterFields 默认的 front matter 字段，未获取到的元数据会被省略，extra 中的键按字母序追加在后面
+func DefaultFrontMatterFields(info *DocumentInfo, extra map[string]interface{}) []FrontMatterField {
+	fields := []FrontMatterField{
+		{"title", info.Title},
+		{"token", info.Token},
+		{"revision_id", info.RevisionID},
+		{"source_url", info.SourceURL},
+		{"created", info.CreatedAt},
+		{"updated", info.UpdatedAt},
+		{"owner", info.Owner},
+	}
+	return appendExtraFields(fields, extra)
+}
+
+func appendExtraFields(fields []FrontMatterField, extra map[string]interface{}) []FrontMatterField {
+	keys := make([]string, 0, len(extra))
+	for key := range extra {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		replaced := false
+		for i := range fields {
+			if fields[i].Key == key {
+				fields[i].Value = extra[key]
+				replaced = true
+			}
+		}
+		if !replaced {
+			fields = append(fields, FrontMatterField{key, extra[key]})
+		}
+	}
+	return fields
+}
+
+// RenderFrontMatter 按 format 渲染 front matter，format 为 none 或未知格式时返回空字符串
+func RenderFrontMatter(format string, fields []FrontMatterField) string {
+	var delimiter, separator string
+	switch format {
+	case FrontMatterYAML:
+		delimiter, separator = "---", ": "
+	case FrontMatterTOML:
+		delimiter, separator = "+++", " = "
+	default:
+		return ""
+	}
+
+	buf := new(strings.Builder)
+	buf.WriteString(delimiter + "\n")
+	for _, field := range fields {
+		value, ok := frontMatterValue(field.Value)
+		if !ok {
+			continue
+		}
+		key := field.Key
+		if !isBareKey(key) {
+			key = quoteFrontMatterString(key)
+		}
+		buf.WriteString(key + separator + value + "\n")
+	}
+	buf.WriteString(delimiter + "\n\n")
+	return buf.String()
+}
+
+// frontMatterValue 将值编码为 YAML 和 TOML 通用的字面量，空值返回 false
+func frontMatterValue(v interface{}) (string, bool) {
+	switch v := v.(type) {
+	case nil:
+		return "", false
+	case string:
+		if v == "" {
+			return "", false
+		}
+		return quoteFrontMatterString(v), true
+	case bool:
+		return strconv.FormatBool(v), true
+	case int:
+		if v == 0 {
+			return "", false
+		}
+		return strconv.Itoa(v), true
+	case int64:
+		if v == 0 {
+			return "", false
+		}
+		return strconv.FormatInt(v, 10), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case time.Time:
+		if v.IsZero() {
+			return "", false
+		}
+		return v.Format(time.RFC3339), true
+	case []string:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = item
+		}
+		return frontMatterValue(items)
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if value, ok := frontMatterValue(item); ok {
+				values = append(values, value)
+			}
+		}
+		return "[" + strings.Join(values, ", ") + "]", true
+	default:
+		// 嵌套结构以 JSON 字符串输出
+		data, err := json.Marshal(v)
+		if err != nil {
+			return "", false
+		}
+		return quoteFrontMatterString(string(data)), true
+	}
+}
+
+// quoteFrontMatterString 输出 YAML 和 TOML 都能解析的双引号字符串
+func quoteFrontMatterString(s string) string {
+	buf := new(strings.Builder)
+	buf.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				buf.WriteString(fmt.Sprintf(`\u%04x`, r))
+			} else {
+				buf.WriteRune(r)
+			}
+		}
+	}
+	buf.WriteByte('"')
+	return buf.String()
+}
+
+func isBareKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for _, r := range key {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,77 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/chyroc/lark"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetDocumentMeta(t *testing.T) {
+	c := NewClient(FeishuConfig{AppId: "app_id", AppSecret: "app_secret"})
+	c.larkClient.Mock().MockDriveGetDriveFileMeta(func(ctx context.Context, request *lark.GetDriveFileMetaReq, options ...lark.MethodOptionFunc) (*lark.GetDriveFileMetaResp, *lark.Response, error) {
+		assert.Equal(t, "doxcnToken", request.RequestDocs[0].DocToken)
+		assert.Equal(t, "docx", request.RequestDocs[0].DocType)
+		return &lark.GetDriveFileMetaResp{
+			Metas: []*lark.GetDriveFileMetaRespMeta{{
+				DocToken:         "doxcnToken",
+				OwnerID:          "ou_owner",
+				CreateTime:       "1700000000",
+				LatestModifyTime: "1700003600",
+			}},
+		}, nil, nil
+	})
+
+	meta, err := c.GetDocumentMeta(context.Background(), "doxcnToken", "docx")
+	assert.NoError(t, err)
+	assert.Equal(t, "ou_owner", meta.OwnerID)
+	assert.Equal(t, int64(1700000000), meta.CreatedAt.Unix())
+	assert.Equal(t, int64(1700003600), meta.UpdatedAt.Unix())
+}
+
+func TestRenderFrontMatter(t *testing.T) {
+	info := &DocumentInfo{
+		Title:      `设计 "v2"`,
+		Token:      "doxcnToken",
+		RevisionID: 42,
+		SourceURL:  "https://sample.feishu.cn/docx/doxcnToken",
+		UpdatedAt:  time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Owner:      "Alice",
+	}
+	extra := map[string]interface{}{
+		"draft": false,
+		"tags":  []interface{}{"arch", "rfc"},
+		"owner": "Team A",
+	}
+	fields := DefaultFrontMatterFields(info, extra)
+
+	assert.Equal(t, `---
+title: "设计 \"v2\""
+token: "doxcnToken"
+revision_id: 42
+source_url: "https://sample.feishu.cn/docx/doxcnToken"
+updated: 2024-01-02T03:04:05Z
+owner: "Team A"
+draft: false
+tags: ["arch", "rfc"]
+---
+
+`, RenderFrontMatter(FrontMatterYAML, fields))
+
+	assert.Equal(t, `+++
+title = "设计 \"v2\""
+token = "doxcnToken"
+revision_id = 42
+source_url = "https://sample.feishu.cn/docx/doxcnToken"
+updated = 2024-01-02T03:04:05Z
+owner = "Team A"
+draft = false
+tags = ["arch", "rfc"]
++++
+
+`, RenderFrontMatter(FrontMatterTOML, fields))
+
+	assert.Empty(t, RenderFrontMatter(FrontMatterNone, fields))
+}
@@ -0,0 +1,311 @@
+package core
+
+import (
+	"encoding/base64"
+	"fmt"
+	"html"
+	"html/template"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultHTMLTemplate 默认的 HTML 页面模板，引入 KaTeX 渲染公式。
+// 自定义模板可使用 .Title（文档标题）和 .Content（正文 HTML）
+const DefaultHTMLTemplate = `<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<link rel="stylesheet" href="https://cdn.jsdelivr.net/npm/katex@0.16.9/dist/katex.min.css">
+<script defer src="https://cdn.jsdelivr.net/npm/katex@0.16.9/dist/katex.min.js"></script>
+<script defer src="https://cdn.jsdelivr.net/npm/katex@0.16.9/dist/contrib/auto-render.min.js" onload="renderMathInElement(document.body)"></script>
+<style>
+body { max-width: 860px; margin: 2rem auto; padding: 0 1rem; line-height: 1.6; font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", "PingFang SC", "Microsoft YaHei", sans-serif; }
+img { max-width: 100%; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #dee0e3; padding: 4px 8px; }
+pre { background: #f5f6f7; padding: 12px; overflow-x: auto; }
+blockquote { margin: 1em 0; padding-left: 1em; border-left: 4px solid #dee0e3; color: #646a73; }
+.callout { margin: 1em 0; padding: 8px 16px; border-radius: 6px; background: #f0f4ff; }
+.callout-tip { background: #f0fbef; }
+.callout-important { background: #f6f1fe; }
+.callout-warning { background: #fef7e6; }
+.callout-caution { background: #fef1f1; }
+</style>
+</head>
+<body>
+{{.Content}}
+</body>
+</html>
+`
+
+// HTMLEmitter 将 AST 输出为 HTML 正文，可再通过 RenderHTMLPage 套用页面模板
+type HTMLEmitter struct {
+	mentionMode string
+	placeholder bool
+	toc         bool
+}
+
+func NewHTMLEmitter(config OutputConfig) *HTMLEmitter {
+	return &HTMLEmitter{mentionMode: config.MentionMode, placeholder: config.UnsupportedPlaceholder, toc: config.TOC}
+}
+
+// Emit 输出整篇文档，标题为 <h1>，开启 toc 时标题后为目录
+func (e *HTMLEmitter) Emit(doc *Document) string {
+	buf := new(strings.Builder)
+	buf.WriteString("<h1>" + e.EmitInlines(doc.Title) + "</h1>\n")
+	if e.toc {
+		buf.WriteString(renderHTMLTOC(tocEntries(doc.Children)))
+	}
+	buf.WriteString(e.EmitBlocks(doc.Children))
+	return buf.String()
+}
+
+// EmitBlocks 依次输出块级节点
+func (e *HTMLEmitter) EmitBlocks(blocks []*Block) string {
+	buf := new(strings.Builder)
+	for _, b := range blocks {
+		buf.WriteString(e.EmitBlock(b))
+	}
+	return buf.String()
+}
+
+// EmitBlock 输出单个块级节点，不支持的节点在未开启占位时输出空字符串
+func (e *HTMLEmitter) EmitBlock(b *Block) string {
+	switch b.Type {
+	case NodeHeading:
+		level := 1
+		fmt.Sscan(b.Attrs["level"], &level)
+		// HTML 只有六级标题
+		level = min(max(level, 1), 6)
+		id := ""
+		if anchor := b.Attrs["anchor"]; anchor != "" {
+			id = fmt.Sprintf(` id="%s"`, html.EscapeString(anchor))
+		}
+		return fmt.Sprintf("<h%d%s>%s</h%d>\n", level, id, e.EmitInlines(b.Inlines), level)
+	case NodeParagraph:
+		return "<p>" + e.EmitInlines(b.Inlines) + "</p>\n"
+	case NodeList:
+		return e.emitList(b)
+	case NodeCode:
+		class := ""
+		if language := b.Attrs["language"]; language != "" {
+			class = fmt.Sprintf(` class="language-%s"`, html.EscapeString(language))
+		}
+		return fmt.Sprintf("<pre><code%s>%s</code></pre>\n", class, html.EscapeString(b.Text))
+	case NodeQuote:
+		return "<blockquote>\n" + e.EmitBlocks(b.Children) + "</blockquote>\n"
+	case NodeCallout:
+		calloutType := strings.ToLower(b.Attrs["callout_type"])
+		buf := new(strings.Builder)
+		buf.WriteString(fmt.Sprintf(`<div class="callout callout-%s">`+"\n", html.EscapeString(calloutType)))
+		if emoji := b.Attrs["emoji"]; emoji != "" {
+			buf.WriteString(`<p class="callout-title">` + html.EscapeString(emoji) + "</p>\n")
+		}
+		buf.WriteString(e.EmitBlocks(b.Children))
+		buf.WriteString("</div>\n")
+		return buf.String()
+	case NodeEquation:
+		return `<div class="math">\[` + html.EscapeString(b.Text) + `\]</div>` + "\n"
+	case NodeDivider:
+		return "<hr>\n"
+	case NodeImage:
+		attributes := ""
+		if width, height := b.Attrs["width"], b.Attrs["height"]; width != "" && height != "" {
+			attributes = fmt.Sprintf(` width="%s" height="%s"`, width, height)
+		}
+		caption := html.EscapeString(b.Attrs["caption"])
+		attributes += fmt.Sprintf(` alt="%s"`, caption)
+		if caption != "" {
+			attributes += fmt.Sprintf(` title="%s"`, caption)
+		}
+		paragraph := "<p>"
+		if align := b.Attrs["align"]; align == "center" || align == "right" {
+			paragraph = fmt.Sprintf(`<p style="text-align:%s">`, align)
+		}
+		return fmt.Sprintf(`%s<img src="%s"%s></p>`+"\n", paragraph, html.EscapeString(assetSrc(b.Attrs)), attributes)
+	case NodeFile:
+		return fmt.Sprintf(`<p><a href="%s">%s</a></p>`+"\n",
+			html.EscapeString(assetSrc(b.Attrs)), html.EscapeString(b.Attrs["name"]))
+	case NodeTable:
+		return e.emitTable(b)
+	case NodeEmbed:
+		name := embedNames[b.Attrs["kind"]]
+		switch {
+		case b.Attrs["file"] != "":
+			return fmt.Sprintf(`<p><a href="%s">%s</a></p>`+"\n", html.EscapeString(b.Attrs["file"]), name)
+		case len(b.Children) > 0 && b.Children[0].Type == NodeTable:
+			return e.emitTable(b.Children[0])
+		case b.Attrs["url"] == "":
+			return fmt.Sprintf("<p>%s: <code>%s</code></p>\n", name, html.EscapeString(b.Attrs["token"]))
+		}
+		return fmt.Sprintf(`<p><a href="%s">%s</a></p>`+"\n", html.EscapeString(b.Attrs["url"]), name)
+	case NodeHTML:
+		return strings.TrimSuffix(b.Text, "\n") + "\n"
+	case NodeUnsupported:
+		if e.placeholder {
+			return unsupportedNodePlaceholder(b) + "\n"
+		}
+	}
+	return ""
+}
+
+func (e *HTMLEmitter) emitList(b *Block) string {
+	tag := "ul"
+	start := ""
+	if b.Attrs["ordered"] == "true" {
+		tag = "ol"
+		if b.Attrs["start"] != "" && b.Attrs["start"] != "1" {
+			start = fmt.Sprintf(` start="%s"`, html.EscapeString(b.Attrs["start"]))
+		}
+	}
+	buf := new(strings.Builder)
+	buf.WriteString(fmt.Sprintf("<%s%s>\n", tag, start))
+	for _, item := range b.Children {
+		buf.WriteString("<li>")
+		switch item.Attrs["checked"] {
+		case "true":
+			buf.WriteString(`<input type="checkbox" checked disabled> `)
+		case "false":
+			buf.WriteString(`<input type="checkbox" disabled> `)
+		}
+		buf.WriteString(e.EmitInlines(item.Inlines))
+		if len(item.Children) > 0 {
+			buf.WriteString("\n" + e.EmitBlocks(item.Children))
+		}
+		buf.WriteString("</li>\n")
+	}
+	buf.WriteString(fmt.Sprintf("</%s>\n", tag))
+	return buf.String()
+}
+
+// emitTable 输出表格，保留合并单元格，设置了标题行时首行输出为 <th>
+func (e *HTMLEmitter) emitTable(b *Block) string {
+	buf := new(strings.Builder)
+	buf.WriteString("<table>\n")
+	for rowIndex, row := range b.Children {
+		cellTag := "td"
+		if rowIndex == 0 && b.Attrs["header_row"] == "true" {
+			cellTag = "th"
+		}
+		buf.WriteString("<tr>\n")
+		for _, cell := range row.Children {
+			attributes := ""
+			if rowSpan := cell.Attrs["rowspan"]; rowSpan != "" {
+				attributes += fmt.Sprintf(` rowspan="%s"`, rowSpan)
+			}
+			if colSpan := cell.Attrs["colspan"]; colSpan != "" {
+				attributes += fmt.Sprintf(` colspan="%s"`, colSpan)
+			}
+			content := e.EmitBlocks(cell.Children)
+			// 只有一个段落时去掉 <p>，让单元格更紧凑
+			if len(cell.Children) == 1 && cell.Children[0].Type == NodeParagraph {
+				content = e.EmitInlines(cell.Children[0].Inlines)
+			}
+			buf.WriteString(fmt.Sprintf("<%s%s>%s</%s>\n", cellTag, attributes, strings.TrimSuffix(content, "\n"), cellTag))
+		}
+		buf.WriteString("</tr>\n")
+	}
+	buf.WriteString("</table>\n")
+	return buf.String()
+}
+
+// EmitInlines 输出一段文本的行内节点
+func (e *HTMLEmitter) EmitInlines(inlines []*Inline) string {
+	buf := new(strings.Builder)
+	for _, i := range inlines {
+		buf.WriteString(e.EmitInline(i))
+	}
+	return buf.String()
+}
+
+// EmitInline 输出单个行内节点
+func (e *HTMLEmitter) EmitInline(i *Inline) string {
+	switch i.Type {
+	case NodeText:
+		return e.emitText(i)
+	case NodeMentionUser:
+		name := i.Attrs["name"]
+		if name == "" {
+			return html.EscapeString(i.Attrs["user_id"])
+		}
+		if email := i.Attrs["email"]; email != "" && e.mentionMode == MentionModeMailto {
+			return fmt.Sprintf(`<a href="mailto:%s">@%s</a>`, html.EscapeString(email), html.EscapeString(name))
+		}
+		return "@" + html.EscapeString(name)
+	case NodeMentionDoc:
+		return fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(i.Attrs["href"]), html.EscapeString(i.Text))
+	case NodeInlineFormula:
+		return `<span class="math">\(` + html.EscapeString(i.Text) + `\)</span>`
+	}
+	return ""
+}
+
+func (e *HTMLEmitter) emitText(i *Inline) string {
+	content := strings.ReplaceAll(html.EscapeString(i.Text), "\n", "<br>")
+	if content == "" {
+		return ""
+	}
+
+	// 由内向外依次包裹：行内代码、下划线、删除线、斜体、加粗、颜色、链接
+	for _, m := range []struct {
+		mark Mark
+		tag  string
+	}{
+		{MarkCode, "code"},
+		{MarkUnderline, "u"},
+		{MarkStrikethrough, "del"},
+		{MarkItalic, "em"},
+		{MarkBold, "strong"},
+	} {
+		if i.HasMark(m.mark) {
+			content = fmt.Sprintf("<%s>%s</%s>", m.tag, content, m.tag)
+		}
+	}
+	if color, ok := i.Attrs["color"]; ok {
+		content = fmt.Sprintf(`<span style="color:%s">%s</span>`, html.EscapeString(color), content)
+	}
+	if i.HasMark(MarkHighlight) {
+		if color, ok := i.Attrs["background_color"]; ok {
+			content = fmt.Sprintf(`<mark style="background-color:%s">%s</mark>`, html.EscapeString(color), content)
+		} else {
+			content = "<mark>" + content + "</mark>"
+		}
+	}
+	if href, ok := i.Attrs["href"]; ok {
+		content = fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(href), content)
+	}
+	return content
+}
+
+// RenderHTMLPage 用页面模板包裹 HTML 正文，tmpl 为空时使用 DefaultHTMLTemplate
+func RenderHTMLPage(tmpl, title, content string) (string, error) {
+	if tmpl == "" {
+		tmpl = DefaultHTMLTemplate
+	}
+	t, err := template.New("page").Parse(tmpl)
+	if err != nil {
+		return "", err
+	}
+	buf := new(strings.Builder)
+	err = t.Execute(buf, struct {
+		Title   string
+		Content template.HTML
+	}{title, template.HTML(content)})
+	if err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// DataURI 将图片内容编码为 data URI，MIME 类型优先按文件扩展名判断
+func DataURI(name string, data []byte) string {
+	mimeType := mime.TypeByExtension(filepath.Ext(name))
+	if mimeType == "" {
+		mimeType = http.DetectContentType(data)
+	}
+	return fmt.Sprintf("data:%s;base64,%s", mimeType, base64.StdEncoding.EncodeToString(data))
+}
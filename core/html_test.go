@@ -0,0 +1,60 @@
+package core_test
+
+import (
+	"testing"
+
+	"github.com/Wsine/feishu2md/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTMLEmitter(t *testing.T) {
+	doc, blocks := astTestDocx()
+	config := core.NewConfig("", "").Output
+	document := core.NewParser(config).BuildDocument(doc, blocks)
+
+	assert.Equal(t, "<h1>AST</h1>\n"+
+		"<h2>概述</h2>\n"+
+		`<p>见 <a href="https://example.com"><strong>文档 </strong></a><span class="math">\(E=mc^2\)</span></p>`+"\n"+
+		"<ul>\n<li>步骤\n<ol>\n<li>安装</li>\n<li>配置</li>\n</ol>\n</li>\n</ul>\n"+
+		"<ul>\n<li><input type=\"checkbox\" checked disabled> 发布</li>\n</ul>\n"+
+		"<pre><code class=\"language-bash\">go build ./...</code></pre>\n"+
+		"<table>\n<tr>\n<th colspan=\"2\">合并</th>\n</tr>\n<tr>\n<td>a</td>\n<td>b</td>\n</tr>\n</table>\n",
+		core.NewHTMLEmitter(config).Emit(document))
+}
+
+func TestHTMLEmitterEscape(t *testing.T) {
+	document := &core.Document{
+		Title: []*core.Inline{{Type: core.NodeText, Text: "a < b"}},
+		Children: []*core.Block{
+			{Type: core.NodeCode, Text: "if a < b && c > d {}"},
+			{Type: core.NodeParagraph, Inlines: []*core.Inline{
+				{Type: core.NodeText, Text: "<script>", Marks: []core.Mark{core.MarkCode}},
+			}},
+		},
+	}
+	assert.Equal(t, "<h1>a &lt; b</h1>\n"+
+		"<pre><code>if a &lt; b &amp;&amp; c &gt; d {}</code></pre>\n"+
+		"<p><code>&lt;script&gt;</code></p>\n",
+		core.NewHTMLEmitter(core.NewConfig("", "").Output).Emit(document))
+}
+
+func TestRenderHTMLPage(t *testing.T) {
+	page, err := core.RenderHTMLPage("", "a & b", "<p>正文</p>")
+	assert.NoError(t, err)
+	assert.Contains(t, page, "<title>a &amp; b</title>")
+	assert.Contains(t, page, "<p>正文</p>")
+	assert.Contains(t, page, "katex")
+
+	page, err = core.RenderHTMLPage("<article><h1>{{.Title}}</h1>{{.Content}}</article>", "标题", "<p>正文</p>")
+	assert.NoError(t, err)
+	assert.Equal(t, "<article><h1>标题</h1><p>正文</p></article>", page)
+
+	_, err = core.RenderHTMLPage("{{.Title", "标题", "")
+	assert.Error(t, err)
+}
+
+func TestDataURI(t *testing.T) {
+	assert.Equal(t, "data:image/png;base64,iVBORw==", core.DataURI("static/a.png", []byte("\x89PNG")))
+	// 没有扩展名时按内容判断
+	assert.Equal(t, "data:image/png;base64,iVBORw0KGgo=", core.DataURI("static/a", []byte("\x89PNG\r\n\x1a\n")))
+}
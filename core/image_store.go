@@ -0,0 +1,225 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// 图片文件的命名方式
+const (
+	ImageNamingToken  = "token"  // <图片 token>.<扩展名>
+	ImageNamingSHA256 = "sha256" // <内容的 SHA-256>.<扩展名>，内容相同的图片只保存一份
+)
+
+// AssetCache 缓存清单中的一张图片
+type AssetCache struct {
+	SHA256 string   `json:"sha256"` // 图片内容的 SHA-256
+	Files  []string `json:"files"`  // 相对缓存所在目录的文件路径，按文档分目录保存时同一张图片可能有多份
+}
+
+// ImageFetcher 下载图片，返回飞书中的文件名（用于确定扩展名）和图片内容
+type ImageFetcher func() (name string, data []byte, err error)
+
+// ImageFileName 按 naming 返回图片保存的文件名
+func ImageFileName(naming, token, sum, ext string) string {
+	if naming == ImageNamingSHA256 {
+		return sum + ext
+	}
+	return token + ext
+}
+
+// SaveImage 下载图片并保存到 imageDir 中，返回本地路径和内容的 SHA-256。
+// 按内容命名时目录中已有同名文件则不再写入
+func SaveImage(token, imageDir, naming string, fetch ImageFetcher) (string, string, error) {
+	name, data, err := fetch()
+	if err != nil {
+		return "", "", err
+	}
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	path := filepath.Join(imageDir, ImageFileName(naming, token, hash, filepath.Ext(name)))
+	if naming == ImageNamingSHA256 {
+		if _, err := os.Stat(path); err == nil {
+			return path, hash, nil
+		}
+	}
+	if err := os.MkdirAll(imageDir, 0o755); err != nil {
+		return "", "", err
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", "", err
+	}
+	return path, hash, nil
+}
+
+// StoreImage 将 token 对应的图片保存到 imageDir 中并记录到清单，返回本地路径。
+// 清单中已有该图片时不再下载：imageDir 中已有则直接使用，否则从清单中的其他位置复制
+func (cm *CacheManager) StoreImage(token, imageDir, naming string, fetch ImageFetcher) (string, error) {
+	if path, ok := cm.reuseImage(token, imageDir, naming); ok {
+		return path, nil
+	}
+	path, hash, err := SaveImage(token, imageDir, naming, fetch)
+	if err != nil {
+		return "", err
+	}
+	cm.addImageFile(token, hash, path)
+	return path, nil
+}
+
+// reuseImage 在清单中查找已下载的图片，需要时复制到 imageDir 中
+func (cm *CacheManager) reuseImage(token, imageDir, naming string) (string, bool) {
+	cm.mutex.RLock()
+	asset, exists := cm.Assets[token]
+	var hash string
+	var files []string
+	if exists {
+		hash = asset.SHA256
+		files = append(files, asset.Files...)
+	}
+	cm.mutex.RUnlock()
+	if !exists || len(files) == 0 {
+		return "", false
+	}
+
+	path := filepath.Join(imageDir, ImageFileName(naming, token, hash, filepath.Ext(files[0])))
+	if _, err := os.Stat(path); err == nil {
+		cm.addImageFile(token, hash, path)
+		return path, true
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(cm.cachePath(file))
+		if err != nil {
+			continue
+		}
+		// 文件被修改过时不再复用，重新下载
+		if sum := sha256.Sum256(data); hex.EncodeToString(sum[:]) != hash {
+			continue
+		}
+		if err := os.MkdirAll(imageDir, 0o755); err != nil {
+			return "", false
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			return "", false
+		}
+		cm.addImageFile(token, hash, path)
+		return path, true
+	}
+	return "", false
+}
+
+// addImageFile 将图片文件记录到清单中
+func (cm *CacheManager) addImageFile(token, hash, path string) {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+
+	if cm.Assets == nil {
+		cm.Assets = make(map[string]*AssetCache)
+	}
+	asset, exists := cm.Assets[token]
+	if !exists || asset.SHA256 != hash {
+		asset = &AssetCache{SHA256: hash}
+		cm.Assets[token] = asset
+	}
+	file := cm.relPath(path)
+	for _, f := range asset.Files {
+		if f == file {
+			return
+		}
+	}
+	asset.Files = append(asset.Files, file)
+	cm.dirty = true
+}
+
+// UpdateDocumentImages 记录文档引用的图片文件，用于清理不再被引用的图片
+func (cm *CacheManager) UpdateDocumentImages(docToken string, paths []string) {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+
+	cache, exists := cm.Documents[docToken]
+	if !exists {
+		return
+	}
+	images := make([]string, 0, len(paths))
+	for _, path := range paths {
+		images = append(images, cm.relPath(path))
+	}
+	sort.Strings(images)
+	cache.Images = images
+	cm.dirty = true
+}
+
+// UnrecordedImageDocuments 返回缓存中没有图片记录的文档数量，如在记录图片之前同步且之后未修改的文档
+func (cm *CacheManager) UnrecordedImageDocuments() int {
+	cm.mutex.RLock()
+	defer cm.mutex.RUnlock()
+
+	count := 0
+	for _, doc := range cm.Documents {
+		if doc.Images == nil {
+			count++
+		}
+	}
+	return count
+}
+
+// PruneImages 删除清单中不再被任何缓存文档引用的图片文件，返回被删除的文件路径
+func (cm *CacheManager) PruneImages() ([]string, error) {
+	cm.mutex.Lock()
+	defer cm.mutex.Unlock()
+
+	referenced := make(map[string]bool)
+	for _, doc := range cm.Documents {
+		for _, image := range doc.Images {
+			referenced[image] = true
+		}
+	}
+
+	var removed []string
+	tokens := make([]string, 0, len(cm.Assets))
+	for token := range cm.Assets {
+		tokens = append(tokens, token)
+	}
+	sort.Strings(tokens)
+	for _, token := range tokens {
+		asset := cm.Assets[token]
+		var files []string
+		for _, file := range asset.Files {
+			if referenced[file] {
+				files = append(files, file)
+				continue
+			}
+			// 按内容命名时多个 token 可能共用同一个文件，只删除一次
+			path := cm.cachePath(file)
+			if err := os.Remove(path); err == nil {
+				removed = append(removed, path)
+			} else if !os.IsNotExist(err) {
+				return removed, err
+			}
+		}
+		if len(files) != len(asset.Files) {
+			cm.dirty = true
+		}
+		if len(files) == 0 {
+			delete(cm.Assets, token)
+			continue
+		}
+		asset.Files = files
+	}
+	return removed, nil
+}
+
+// relPath 返回相对缓存所在目录的路径
+func (cm *CacheManager) relPath(path string) string {
+	if relPath, err := filepath.Rel(filepath.Dir(cm.filePath), path); err == nil {
+		path = relPath
+	}
+	return filepath.ToSlash(path)
+}
+
+// cachePath 将相对缓存所在目录的路径还原为本地路径
+func (cm *CacheManager) cachePath(file string) string {
+	return filepath.Join(filepath.Dir(cm.filePath), filepath.FromSlash(file))
+}
@@ -0,0 +1,114 @@
+package core_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Wsine/feishu2md/core"
+	"github.com/stretchr/testify/assert"
+)
+
+// countingFetcher 返回固定内容的图片并记录下载次数
+func countingFetcher(data string, calls *int) core.ImageFetcher {
+	return func() (string, []byte, error) {
+		*calls++
+		return "image.png", []byte(data), nil
+	}
+}
+
+func TestStoreImage(t *testing.T) {
+	outputDir := t.TempDir()
+	cm, err := core.NewCacheManager(outputDir)
+	assert.NoError(t, err)
+
+	calls := 0
+	docA := filepath.Join(outputDir, "a", "static")
+	path, err := cm.StoreImage("boxcnImage", docA, core.ImageNamingToken, countingFetcher("png", &calls))
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(docA, "boxcnImage.png"), path)
+	assert.Equal(t, 1, calls)
+
+	// 同一目录中已有的图片直接复用
+	path, err = cm.StoreImage("boxcnImage", docA, core.ImageNamingToken, countingFetcher("png", &calls))
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(docA, "boxcnImage.png"), path)
+	assert.Equal(t, 1, calls)
+
+	// 其他文档的目录中从已下载的图片复制
+	docB := filepath.Join(outputDir, "b", "static")
+	path, err = cm.StoreImage("boxcnImage", docB, core.ImageNamingToken, countingFetcher("png", &calls))
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(docB, "boxcnImage.png"), path)
+	assert.Equal(t, 1, calls)
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "png", string(data))
+
+	// 清单随缓存一起保存
+	assert.NoError(t, cm.Save())
+	reloaded, err := core.NewCacheManager(outputDir)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a/static/boxcnImage.png", "b/static/boxcnImage.png"}, reloaded.Assets["boxcnImage"].Files)
+
+	// 文件被删除后重新下载
+	assert.NoError(t, os.Remove(filepath.Join(docA, "boxcnImage.png")))
+	assert.NoError(t, os.Remove(filepath.Join(docB, "boxcnImage.png")))
+	_, err = reloaded.StoreImage("boxcnImage", docA, core.ImageNamingToken, countingFetcher("png", &calls))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, calls)
+}
+
+func TestStoreImageSHA256(t *testing.T) {
+	outputDir := t.TempDir()
+	cm, err := core.NewCacheManager(outputDir)
+	assert.NoError(t, err)
+
+	// 内容相同的图片只保存一份
+	calls := 0
+	imageDir := filepath.Join(outputDir, "static")
+	first, err := cm.StoreImage("boxcnFirst", imageDir, core.ImageNamingSHA256, countingFetcher("same", &calls))
+	assert.NoError(t, err)
+	second, err := cm.StoreImage("boxcnSecond", imageDir, core.ImageNamingSHA256, countingFetcher("same", &calls))
+	assert.NoError(t, err)
+	assert.Equal(t, first, second)
+	assert.Equal(t, filepath.Join(imageDir, "0967115f2813a3541eaef77de9d9d5773f1c0c04314b0bbfe4ff3b3b1c55b5d5.png"), first)
+	entries, err := os.ReadDir(imageDir)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	_, err = cm.StoreImage("boxcnFailed", imageDir, core.ImageNamingSHA256, func() (string, []byte, error) {
+		return "", nil, errors.New("download failed")
+	})
+	assert.Error(t, err)
+}
+
+func TestPruneImages(t *testing.T) {
+	outputDir := t.TempDir()
+	cm, err := core.NewCacheManager(outputDir)
+	assert.NoError(t, err)
+
+	calls := 0
+	imageDir := filepath.Join(outputDir, "static")
+	kept, err := cm.StoreImage("boxcnKept", imageDir, core.ImageNamingToken, countingFetcher("kept", &calls))
+	assert.NoError(t, err)
+	dropped, err := cm.StoreImage("boxcnDropped", imageDir, core.ImageNamingToken, countingFetcher("dropped", &calls))
+	assert.NoError(t, err)
+
+	cm.UpdateDocument("doxcnDoc", 1, "文档", "doxcnDoc.md", "docx")
+	assert.Equal(t, 1, cm.UnrecordedImageDocuments())
+	cm.UpdateDocumentImages("doxcnDoc", []string{kept})
+	assert.Equal(t, 0, cm.UnrecordedImageDocuments())
+
+	// 跳过下载的文档保留原来的图片记录
+	cm.UpdateDocument("doxcnDoc", 1, "文档", "doxcnDoc.md", "docx")
+
+	removed, err := cm.PruneImages()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{dropped}, removed)
+	assert.FileExists(t, kept)
+	assert.NoFileExists(t, dropped)
+	assert.NotContains(t, cm.Assets, "boxcnDropped")
+	assert.Contains(t, cm.Assets, "boxcnKept")
+}
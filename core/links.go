@@ -0,0 +1,135 @@
+package core
+
+import (
+	"html"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// docLinkRegex 匹配 Markdown 链接中指向飞书文档的地址，分组依次为地址、文档 token 和锚点
+var docLinkRegex = regexp.MustCompile(`\]\((https?://[\w-.]+/(?:docx|docs|wiki)/([a-zA-Z0-9]+)[^)#\s]*(#[^)\s]*)?)\)`)
+
+// docHrefRegex 匹配 HTML 链接中指向飞书文档的地址，分组依次为地址、文档 token 和锚点
+var docHrefRegex = regexp.MustCompile(`href="(https?://[\w-.]+/(?:docx|docs|wiki)/([a-zA-Z0-9]+)[^"#\s]*(#[^"\s]*)?)"`)
+
+// wikilinkRegex 匹配完整的 Markdown 链接，分组依次为链接文字、地址、文档 token 和锚点
+var wikilinkRegex = regexp.MustCompile(`\[([^\]]*)` + docLinkRegex.String())
+
+// RewriteDocLinks 将 markdown 中指向 localPaths 内文档的飞书链接改写为相对 mdPath 的本地路径，保留锚点。
+// 同时处理 Markdown 链接和 HTML 的 href 属性，因此也适用于 HTML 文件以及 Markdown 中的 HTML 表格。
+// localPaths 为文档 token（或知识库节点 token）到本地文件路径的映射
+func RewriteDocLinks(markdown, mdPath string, localPaths map[string]string) string {
+	markdown = docLinkRegex.ReplaceAllStringFunc(markdown, func(link string) string {
+		match := docLinkRegex.FindStringSubmatch(link)
+		relPath, ok := localDocPath(mdPath, match[2], localPaths)
+		if !ok {
+			return link
+		}
+		return "](" + escapeLinkPath(relPath) + match[3] + ")"
+	})
+	return docHrefRegex.ReplaceAllStringFunc(markdown, func(link string) string {
+		match := docHrefRegex.FindStringSubmatch(link)
+		relPath, ok := localDocPath(mdPath, match[2], localPaths)
+		if !ok {
+			return link
+		}
+		return `href="` + html.EscapeString(escapeLinkPath(relPath)) + match[3] + `"`
+	})
+}
+
+// localDocPath 返回 token 对应的本地文件相对 mdPath 的斜杠路径，文档未同步时返回 false
+func localDocPath(mdPath, token string, localPaths map[string]string) (string, bool) {
+	target, ok := localPaths[token]
+	if !ok {
+		return "", false
+	}
+	relPath, err := filepath.Rel(filepath.Dir(mdPath), target)
+	if err != nil {
+		return "", false
+	}
+	return filepath.ToSlash(relPath), true
+}
+
+// RewriteDocWikilinks 将 markdown 中指向 localPaths 内文档的飞书链接改写为 Obsidian 的 [[wikilink]]，
+// 链接路径相对 vaultRoot 且不含扩展名。飞书锚点为 Block ID，无法对应到 Obsidian 的标题，因此被丢弃
+func RewriteDocWikilinks(markdown, vaultRoot string, localPaths map[string]string) string {
+	return wikilinkRegex.ReplaceAllStringFunc(markdown, func(link string) string {
+		match := wikilinkRegex.FindStringSubmatch(link)
+		target, ok := localPaths[match[3]]
+		if !ok {
+			return link
+		}
+		relPath, err := filepath.Rel(vaultRoot, target)
+		if err != nil {
+			return link
+		}
+		name := strings.TrimSuffix(filepath.ToSlash(relPath), ".md")
+		text := match[1]
+		if text == "" || text == path.Base(name) {
+			return "[[" + name + "]]"
+		}
+		return "[[" + name + "|" + text + "]]"
+	})
+}
+
+// escapeLinkPath 转义链接路径中会截断 Markdown 链接的字符
+func escapeLinkPath(path string) string {
+	return strings.NewReplacer(" ", "%20", "(", "%28", ")", "%29").Replace(path)
+}
+
+// LocalDocPaths 返回已同步文档的 token 和知识库节点 token 到本地 Markdown 文件路径的映射
+func (cm *CacheManager) LocalDocPaths() map[string]string {
+	cm.mutex.RLock()
+	defer cm.mutex.RUnlock()
+
+	paths := make(map[string]string)
+	for docToken, doc := range cm.Documents {
+		if doc.FilePath == "" {
+			continue
+		}
+		path := filepath.Join(filepath.Dir(cm.filePath), doc.FilePath)
+		paths[docToken] = path
+		if doc.NodeToken != "" {
+			paths[doc.NodeToken] = path
+		}
+	}
+	return paths
+}
+
+// RewriteLocalDocLinks 在全部文档同步完成后，按 flavor 改写已同步文档之间的链接，返回被修改的文件数。
+// Obsidian 风格下 Markdown 链接改写为以输出目录为仓库根目录的 [[wikilink]]，HTML 文件和 href 属性中的链接改写为相对路径
+func (cm *CacheManager) RewriteLocalDocLinks(flavor string) (int, error) {
+	paths := cm.LocalDocPaths()
+	seen := make(map[string]bool)
+	rewritten := 0
+	for _, path := range paths {
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+
+		content, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return rewritten, err
+		}
+		result := string(content)
+		if flavor == FlavorObsidian && filepath.Ext(path) == ".md" {
+			result = RewriteDocWikilinks(result, filepath.Dir(cm.filePath), paths)
+		}
+		result = RewriteDocLinks(result, path, paths)
+		if result == string(content) {
+			continue
+		}
+		if err := os.WriteFile(path, []byte(result), 0o644); err != nil {
+			return rewritten, err
+		}
+		rewritten++
+	}
+	return rewritten, nil
+}
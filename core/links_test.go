@@ -0,0 +1,133 @@
+package core_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Wsine/feishu2md/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRewriteDocLinks(t *testing.T) {
+	localPaths := map[string]string{
+		"doxcnTarget":  "out/设计/目标 文档.md",
+		"wikcnNode":    "out/指南.md",
+		"doxcnSibling": "out/团队/同级.md",
+	}
+
+	tests := []struct {
+		name     string
+		markdown string
+		want     string
+	}{
+		{
+			"docx link with anchor",
+			"见 [目标](https://sample.feishu.cn/docx/doxcnTarget#doxcnBlock)",
+			"见 [目标](../设计/目标%20文档.md#doxcnBlock)",
+		},
+		{
+			"wiki node link with query",
+			"[指南](https://sample.feishu.cn/wiki/wikcnNode?from=from_copylink)",
+			"[指南](../指南.md)",
+		},
+		{
+			"same directory",
+			"[同级](https://sample.feishu.cn/docx/doxcnSibling)",
+			"[同级](同级.md)",
+		},
+		{
+			"document not synced",
+			"[外部](https://sample.feishu.cn/docx/doxcnOther)",
+			"[外部](https://sample.feishu.cn/docx/doxcnOther)",
+		},
+		{
+			"html href",
+			`<td><a href="https://sample.feishu.cn/wiki/wikcnNode?from=a&amp;b=1#part">指南</a></td>`,
+			`<td><a href="../指南.md#part">指南</a></td>`,
+		},
+		{
+			"html href not synced",
+			`<a href="https://sample.feishu.cn/docx/doxcnOther">外部</a>`,
+			`<a href="https://sample.feishu.cn/docx/doxcnOther">外部</a>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, core.RewriteDocLinks(tt.markdown, "out/团队/当前.md", localPaths))
+		})
+	}
+}
+
+func TestRewriteLocalDocLinks(t *testing.T) {
+	outputDir := t.TempDir()
+	cm, err := core.NewCacheManager(outputDir)
+	assert.NoError(t, err)
+
+	sourcePath := filepath.Join(outputDir, "团队", "doxcnSource.md")
+	targetPath := filepath.Join(outputDir, "wiki", "doxcnTarget.md")
+	assert.NoError(t, os.MkdirAll(filepath.Dir(sourcePath), 0o755))
+	assert.NoError(t, os.MkdirAll(filepath.Dir(targetPath), 0o755))
+	assert.NoError(t, os.WriteFile(sourcePath, []byte("[目标](https://sample.feishu.cn/wiki/wikcnTarget#heading)\n"), 0o644))
+	assert.NoError(t, os.WriteFile(targetPath, []byte("# 目标\n"), 0o644))
+
+	cm.UpdateDocument("doxcnSource", 1, "来源", "doxcnSource.md", "docx")
+	cm.UpdateDocumentLocation("doxcnSource", "", sourcePath)
+	cm.UpdateDocument("doxcnTarget", 1, "目标", "doxcnTarget.md", "docx")
+	cm.UpdateDocumentLocation("doxcnTarget", "wikcnTarget", targetPath)
+
+	rewritten, err := cm.RewriteLocalDocLinks(core.FlavorGFM)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, rewritten)
+
+	content, err := os.ReadFile(sourcePath)
+	assert.NoError(t, err)
+	assert.Equal(t, "[目标](../wiki/doxcnTarget.md#heading)\n", string(content))
+
+	// 导出为 HTML 的文档改写 href 属性，Obsidian 风格下也不改写为 wikilink
+	pagePath := filepath.Join(outputDir, "团队", "doxcnPage.html")
+	assert.NoError(t, os.WriteFile(pagePath, []byte(`<p><a href="https://sample.feishu.cn/docx/doxcnTarget">目标</a></p>`), 0o644))
+	cm.UpdateDocument("doxcnPage", 1, "页面", "doxcnPage.html", "docx")
+	cm.UpdateDocumentLocation("doxcnPage", "", pagePath)
+
+	rewritten, err = cm.RewriteLocalDocLinks(core.FlavorObsidian)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, rewritten)
+	content, err = os.ReadFile(pagePath)
+	assert.NoError(t, err)
+	assert.Equal(t, `<p><a href="../wiki/doxcnTarget.md">目标</a></p>`, string(content))
+}
+
+func TestRewriteDocWikilinks(t *testing.T) {
+	localPaths := map[string]string{
+		"doxcnTarget": "vault/设计/目标 文档.md",
+		"wikcnNode":   "vault/指南.md",
+	}
+
+	tests := []struct {
+		name     string
+		markdown string
+		want     string
+	}{
+		{
+			"link text differs from file name",
+			"见 [设计稿](https://sample.feishu.cn/docx/doxcnTarget#doxcnBlock)",
+			"见 [[设计/目标 文档|设计稿]]",
+		},
+		{
+			"link text equals file name",
+			"[指南](https://sample.feishu.cn/wiki/wikcnNode?from=from_copylink)",
+			"[[指南]]",
+		},
+		{
+			"document not synced",
+			"[外部](https://sample.feishu.cn/docx/doxcnOther)",
+			"[外部](https://sample.feishu.cn/docx/doxcnOther)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, core.RewriteDocWikilinks(tt.markdown, "vault", localPaths))
+		})
+	}
+}
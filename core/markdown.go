@@ -0,0 +1,620 @@
+package core
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"text/template"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/88250/lute/parse"
+)
+
+// Emitter 将文档的 AST 输出为目标格式
+type Emitter interface {
+	Emit(doc *Document) string
+}
+
+// MarkdownEmitter 将 AST 输出为 Markdown，选项与 OutputConfig 中的同名配置一致
+type MarkdownEmitter struct {
+	useHTMLTags     bool
+	tableMode       string
+	mentionMode     string
+	mentionTemplate *template.Template
+	colorMode       string
+	flavor          string
+	placeholder     bool
+	imageMode       string
+	toc             bool
+	headingAnchors  bool
+	inHTML          bool // 正在输出 HTML 块（如 HTML 表格）中的内容
+	inCode          bool // 正在输出代码块中的内容
+}
+
+func NewMarkdownEmitter(config OutputConfig) *MarkdownEmitter {
+	var mentionTemplate *template.Template
+	if config.MentionMode == MentionModeTemplate && config.MentionTemplate != "" {
+		// 读取配置时已由 OutputConfig.Validate 检查模板，直接构造的配置中模板有误时退回输出 @姓名
+		mentionTemplate, _ = template.New("mention").Parse(config.MentionTemplate)
+	}
+	return &MarkdownEmitter{
+		useHTMLTags:     config.UseHTMLTags,
+		tableMode:       config.TableMode,
+		mentionMode:     config.MentionMode,
+		mentionTemplate: mentionTemplate,
+		colorMode:       config.ColorMode,
+		flavor:          config.Flavor,
+		placeholder:     config.UnsupportedPlaceholder,
+		imageMode:       config.ImageMode,
+		toc:             config.TOC,
+		headingAnchors:  config.HeadingAnchors,
+	}
+}
+
+// Emit 输出整篇文档，标题为一级标题，开启 toc 时标题后为目录，块之间空一行
+func (e *MarkdownEmitter) Emit(doc *Document) string {
+	buf := new(strings.Builder)
+	buf.WriteString("# " + e.EmitInlines(doc.Title) + "\n\n")
+	if toc := RenderMarkdownTOC(tocEntries(doc.Children)); e.toc && toc != "" {
+		buf.WriteString(toc + "\n")
+	}
+	buf.WriteString(e.EmitBlocks(doc.Children))
+	return buf.String()
+}
+
+// listSeparator 分隔相邻的同类列表，否则 Markdown 会将其合并为一个列表并重新编号
+const listSeparator = "<!-- -->\n"
+
+// EmitBlocks 依次输出块级节点，块之间空一行
+func (e *MarkdownEmitter) EmitBlocks(blocks []*Block) string {
+	parts := make([]string, 0, len(blocks))
+	var last *Block
+	for _, b := range blocks {
+		content := e.EmitBlock(b)
+		if content == "" {
+			continue
+		}
+		if last != nil && last.Type == NodeList && b.Type == NodeList && last.Attrs["ordered"] == b.Attrs["ordered"] {
+			parts = append(parts, listSeparator)
+		}
+		parts = append(parts, content)
+		last = b
+	}
+	return strings.Join(parts, "\n")
+}
+
+// EmitBlock 输出单个块级节点，以换行结尾，不支持的节点在未开启占位时输出空字符串
+func (e *MarkdownEmitter) EmitBlock(b *Block) string {
+	switch b.Type {
+	case NodeHeading:
+		level := 1
+		fmt.Sscan(b.Attrs["level"], &level)
+		// Markdown 只有六级标题，七至九级标题输出为六级标题
+		level = min(max(level, 1), 6)
+		heading := strings.Repeat("#", level) + " " + e.EmitInlines(b.Inlines)
+		if anchor := b.Attrs["anchor"]; e.headingAnchors && anchor != "" {
+			heading += " {#" + anchor + "}"
+		}
+		return heading + "\n"
+	case NodeParagraph:
+		return e.EmitInlines(b.Inlines) + "\n"
+	case NodeList:
+		return e.emitList(b)
+	case NodeCode:
+		return e.emitCode(b)
+	case NodeQuote:
+		return quoteLines(e.EmitBlocks(b.Children))
+	case NodeCallout:
+		return quoteLines(e.calloutHeader(b.Attrs["callout_type"], b.Attrs["emoji"]) + e.EmitBlocks(b.Children))
+	case NodeEquation:
+		return "$$\n" + b.Text + "\n$$\n"
+	case NodeDivider:
+		return "---\n"
+	case NodeImage:
+		return e.image(b.Attrs) + "\n"
+	case NodeFile:
+		return fmt.Sprintf("[%s](%s)\n", b.Attrs["name"], escapeLinkPath(assetSrc(b.Attrs)))
+	case NodeTable:
+		return e.emitTable(b, e.tableMode)
+	case NodeEmbed:
+		return e.emitEmbed(b)
+	case NodeHTML:
+		return strings.TrimSuffix(b.Text, "\n") + "\n"
+	case NodeUnsupported:
+		if e.placeholder {
+			return unsupportedNodePlaceholder(b) + "\n"
+		}
+	}
+	return ""
+}
+
+func (e *MarkdownEmitter) emitList(b *Block) string {
+	buf := new(strings.Builder)
+	order := 1
+	fmt.Sscan(b.Attrs["start"], &order)
+	for _, item := range b.Children {
+		switch {
+		case b.Attrs["ordered"] == "true":
+			buf.WriteString(fmt.Sprintf("%d. ", order))
+			order++
+		case item.Attrs["checked"] == "true":
+			buf.WriteString("- [x] ")
+		case item.Attrs["checked"] == "false":
+			buf.WriteString("- [ ] ")
+		default:
+			buf.WriteString("- ")
+		}
+		buf.WriteString(e.EmitInlines(item.Inlines) + "\n")
+		if children := e.EmitBlocks(item.Children); children != "" {
+			buf.WriteString(indentLines(children))
+		}
+	}
+	return buf.String()
+}
+
+// embedNames 内嵌内容在链接中显示的名称
+var embedNames = map[string]string{"sheet": "Sheet", "bitable": "Bitable", "board": "Board"}
+
+// emitEmbed 输出内嵌内容：导出为附属文件时输出指向该文件的链接；拉取到数据时输出为表格，
+// 开启 use_html_tags 时为 HTML 表格，否则按 auto 模式输出；其余情况输出指向原始位置的链接
+func (e *MarkdownEmitter) emitEmbed(b *Block) string {
+	name := embedNames[b.Attrs["kind"]]
+	switch {
+	case b.Attrs["file"] != "":
+		return fmt.Sprintf("[%s](%s)\n", name, escapeLinkPath(b.Attrs["file"]))
+	case len(b.Children) > 0 && b.Children[0].Type == NodeTable:
+		// 与文档中的表格使用相同的输出方式，未配置时按是否有合并单元格选择
+		mode := e.tableMode
+		if mode == "" {
+			mode = TableModeAuto
+		}
+		return e.emitTable(b.Children[0], mode)
+	case b.Attrs["url"] == "":
+		return fmt.Sprintf("%s: `%s`\n", name, b.Attrs["token"])
+	}
+	return fmt.Sprintf("[%s](%s)\n", name, b.Attrs["url"])
+}
+
+// emitTable 按 mode 输出表格，auto 模式下没有合并单元格时输出为 GFM 表格，其余情况（包括 mode 为空）输出为 HTML 表格
+func (e *MarkdownEmitter) emitTable(b *Block, mode string) string {
+	if len(b.Children) == 0 {
+		return ""
+	}
+	merged := false
+	for _, row := range b.Children {
+		for _, cell := range row.Children {
+			if cell.Attrs["rowspan"] != "" || cell.Attrs["colspan"] != "" {
+				merged = true
+			}
+		}
+	}
+
+	if mode == TableModeGFM || (mode == TableModeAuto && !merged) {
+		// GFM 表格不支持合并单元格，合并的内容放在左上角，被覆盖的位置留空
+		var rows [][]string
+		covered := map[[2]int]bool{}
+		columns := 0
+		for r, row := range b.Children {
+			var cells []string
+			for _, cell := range row.Children {
+				for covered[[2]int{r, len(cells)}] {
+					cells = append(cells, "")
+				}
+				var parts []string
+				for _, child := range cell.Children {
+					if content := strings.TrimSpace(e.EmitBlock(child)); content != "" {
+						parts = append(parts, content)
+					}
+				}
+				rowSpan, colSpan := 1, 1
+				fmt.Sscan(cell.Attrs["rowspan"], &rowSpan)
+				fmt.Sscan(cell.Attrs["colspan"], &colSpan)
+				for dr := 1; dr < rowSpan; dr++ {
+					for dc := 0; dc < colSpan; dc++ {
+						covered[[2]int{r + dr, len(cells) + dc}] = true
+					}
+				}
+				cells = append(cells, escapeTableCell(strings.Join(parts, " ")))
+				for dc := 1; dc < colSpan; dc++ {
+					cells = append(cells, "")
+				}
+			}
+			for covered[[2]int{r, len(cells)}] {
+				cells = append(cells, "")
+			}
+			columns = max(columns, len(cells))
+			rows = append(rows, cells)
+		}
+		for i := range rows {
+			for len(rows[i]) < columns {
+				rows[i] = append(rows[i], "")
+			}
+		}
+		// GFM 表格必须有表头，未设置标题行时使用空表头
+		if b.Attrs["header_row"] == "false" {
+			rows = append([][]string{make([]string, len(rows[0]))}, rows...)
+		}
+		return renderMarkdownTable(rows)
+	}
+
+	// HTML 表格中的内容不会被解析为 Markdown，文本只做 HTML 转义
+	cellEmitter := e.htmlContent()
+	buf := new(strings.Builder)
+	buf.WriteString("<table>\n")
+	for _, row := range b.Children {
+		buf.WriteString("<tr>\n")
+		for _, cell := range row.Children {
+			attributes := ""
+			if rowSpan := cell.Attrs["rowspan"]; rowSpan != "" {
+				attributes += fmt.Sprintf(` rowspan="%s"`, rowSpan)
+			}
+			if colSpan := cell.Attrs["colspan"]; colSpan != "" {
+				attributes += fmt.Sprintf(` colspan="%s"`, colSpan)
+			}
+			var parts []string
+			for _, child := range cell.Children {
+				content := strings.TrimSuffix(cellEmitter.EmitBlock(child), "\n")
+				parts = append(parts, strings.ReplaceAll(content, "\n", "<br/>"))
+			}
+			buf.WriteString(fmt.Sprintf("<td%s>%s</td>", attributes, strings.Join(parts, "<br/>")))
+		}
+		buf.WriteString("</tr>\n")
+	}
+	buf.WriteString("</table>\n")
+	return buf.String()
+}
+
+// calloutHeader 输出 admonition 的首行，Obsidian 的 callout 类型习惯使用小写
+func (e *MarkdownEmitter) calloutHeader(calloutType, emoji string) string {
+	if e.flavor == FlavorObsidian {
+		calloutType = strings.ToLower(calloutType)
+	}
+	header := fmt.Sprintf("[!%s]", calloutType)
+	if emoji != "" {
+		header += " " + emoji
+	}
+	return header + "\n"
+}
+
+// embedImage 输出图片引用，Obsidian 风格下为 ![[…]] 嵌入
+func (e *MarkdownEmitter) embedImage(target string) string {
+	if e.flavor == FlavorObsidian {
+		return fmt.Sprintf("![[%s]]", target)
+	}
+	return fmt.Sprintf("![](%s)", escapeLinkPath(target))
+}
+
+// image 输出图片。image_mode 为 html 或开启 use_html_tags 时输出为带尺寸和对齐方式的 <img>，
+// 否则输出为标准 Markdown 图片，图片描述作为替代文本和标题
+func (e *MarkdownEmitter) image(attrs map[string]string) string {
+	src, caption := assetSrc(attrs), attrs["caption"]
+	if e.useHTMLTags || e.imageMode == ImageModeHTML {
+		img := fmt.Sprintf(`<img src="%s" alt="%s"`, html.EscapeString(src), html.EscapeString(caption))
+		if caption != "" {
+			img += fmt.Sprintf(` title="%s"`, html.EscapeString(caption))
+		}
+		if width, height := attrs["width"], attrs["height"]; width != "" && height != "" {
+			img += fmt.Sprintf(` width="%s" height="%s"`, width, height)
+		}
+		img += ">"
+		if align := attrs["align"]; align == "center" || align == "right" {
+			img = fmt.Sprintf(`<p align="%s">%s</p>`, align, img)
+		}
+		return img
+	}
+	if caption == "" || e.flavor == FlavorObsidian {
+		return e.embedImage(src)
+	}
+	return fmt.Sprintf(`![%s](%s "%s")`, escapeMarkdown(caption, false, ""), escapeLinkPath(src), imageTitleEscaper.Replace(caption))
+}
+
+// imageTitleEscaper 转义图片标题中会提前结束引号的字符
+var imageTitleEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// EmitInlines 输出一段文本的行内节点，公式单独成段时输出为块级公式。
+// 节点从后往前输出，转义时可以根据段落中之后的内容判断分隔符能否配对
+func (e *MarkdownEmitter) EmitInlines(inlines []*Inline) string {
+	lineStarts := make([]bool, len(inlines))
+	lineStart := true
+	for n, i := range inlines {
+		lineStarts[n] = lineStart
+		switch {
+		case i.Type == NodeText && i.Text == "":
+		case i.Type == NodeText:
+			lineStart = strings.HasSuffix(i.Text, "\n")
+		default:
+			lineStart = false
+		}
+	}
+
+	parts := make([]string, len(inlines))
+	following := ""
+	for n := len(inlines) - 1; n >= 0; n-- {
+		parts[n] = e.emitInline(inlines[n], len(inlines) > 1, lineStarts[n], following)
+		following = parts[n] + following
+	}
+	return following
+}
+
+// EmitInline 输出单个行内节点，inline 表示该节点与其他内容处于同一段落
+func (e *MarkdownEmitter) EmitInline(i *Inline, inline bool) string {
+	return e.emitInline(i, inline, true, "")
+}
+
+// emitInline 输出单个行内节点，lineStart 表示该节点位于行首，此时需要转义行首的块级标记；
+// following 为同一段落中该节点之后已输出的内容
+func (e *MarkdownEmitter) emitInline(i *Inline, inline, lineStart bool, following string) string {
+	switch i.Type {
+	case NodeText:
+		return e.emitText(i, lineStart, following)
+	case NodeMentionUser:
+		return e.emitMentionUser(i)
+	case NodeMentionDoc:
+		return fmt.Sprintf("[%s](%s)", e.escapeText(i.Text, false, ""), escapeLinkPath(i.Attrs["href"]))
+	case NodeInlineFormula:
+		if inline {
+			return "$" + i.Text + "$"
+		}
+		return "$$" + i.Text + "$$"
+	}
+	return ""
+}
+
+func (e *MarkdownEmitter) emitText(i *Inline, lineStart bool, following string) string {
+	if len(i.Marks) == 0 && len(i.Attrs) == 0 {
+		return e.escapeText(i.Text, lineStart, following)
+	}
+
+	// 强调标记紧贴空白时无法被识别，因此把首尾空白移到标记外面
+	content := strings.TrimLeftFunc(i.Text, unicode.IsSpace)
+	leading := i.Text[:len(i.Text)-len(content)]
+	content = strings.TrimRightFunc(content, unicode.IsSpace)
+	trailing := i.Text[len(leading)+len(content):]
+	if content == "" {
+		return i.Text
+	}
+
+	// 由内向外依次包裹：行内代码、下划线、删除线、斜体、加粗、颜色、链接
+	if i.HasMark(MarkCode) {
+		content = codeSpan(content)
+	} else {
+		// 被标记包裹后不再位于行首。链接文字单独解析，不会与链接之外的分隔符配对
+		if i.Attrs["href"] != "" {
+			following = ""
+		}
+		content = e.escapeText(content, lineStart && leading == "" && len(i.Marks) == 0 && i.Attrs["href"] == "", following)
+	}
+	if i.HasMark(MarkUnderline) {
+		content = "<u>" + content + "</u>"
+	}
+	if i.HasMark(MarkStrikethrough) {
+		if e.useHTMLTags {
+			content = "<del>" + content + "</del>"
+		} else {
+			content = "~~" + content + "~~"
+		}
+	}
+	if i.HasMark(MarkItalic) {
+		if e.useHTMLTags {
+			content = "<em>" + content + "</em>"
+		} else {
+			content = "_" + content + "_"
+		}
+	}
+	if i.HasMark(MarkBold) {
+		if e.useHTMLTags {
+			content = "<strong>" + content + "</strong>"
+		} else {
+			content = "**" + content + "**"
+		}
+	}
+	content = e.renderColor(content, i)
+	if href, ok := i.Attrs["href"]; ok {
+		content = fmt.Sprintf("[%s](%s)", content, escapeLinkPath(href))
+	}
+	return leading + content + trailing
+}
+
+// escapeText 按输出位置转义文本：HTML 表格中的内容不会被解析为 Markdown，只需转义 HTML 字符
+func (e *MarkdownEmitter) escapeText(text string, lineStart bool, following string) string {
+	if e.inCode {
+		return text
+	}
+	if e.inHTML {
+		return html.EscapeString(text)
+	}
+	return escapeMarkdown(text, lineStart, following)
+}
+
+// htmlContent 返回用于输出 HTML 块中内容的 Emitter
+func (e *MarkdownEmitter) htmlContent() *MarkdownEmitter {
+	c := *e
+	c.inHTML = true
+	return &c
+}
+
+// markdownEscaper 转义在行内任意位置都可能开始 Markdown 语法的字符，
+// 包括强调、行内代码、链接、HTML 标签和表格分隔符
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "*", `\*`, "`", "\\`", "[", `\[`, "]", `\]`,
+	"<", `\<`, "|", `\|`,
+)
+
+// emojiAliasRegex 匹配可能被解析为 Emoji 的别名，如 :smile:，只有已知的别名才会被替换
+var emojiAliasRegex = regexp.MustCompile(`^:([^\s:]+):`)
+
+// emailRegex 匹配 @ 之后仍是邮箱地址的内容
+var emailRegex = regexp.MustCompile(`^@[A-Za-z0-9._-]`)
+
+// entityRegex 匹配会被解码的 HTML 实体，如 &amp;、&#39; 和 &#x27;
+var entityRegex = regexp.MustCompile(`^&(?:[A-Za-z][A-Za-z0-9]*|#[0-9]{1,7}|#[xX][0-9A-Fa-f]{1,6});`)
+
+// lineStartRegex 匹配行首会被解释为块级标记的内容：标题、引用、列表标记、YAML front matter、
+// 分割线、setext 标题的下划线和表格的分隔行
+var lineStartRegex = regexp.MustCompile(`^(#{1,6}(?:[ \t]|$)|>|[-+*](?:[ \t]|$)|---|[0-9]{1,9}[.)](?:[ \t]|$)|[-=_*: \t]+$)`)
+
+// EscapeMarkdown 转义位于行首的文本中会被解释为 Markdown 或 HTML 的字符，使其按原样显示
+func EscapeMarkdown(text string) string {
+	return escapeMarkdown(text, true, "")
+}
+
+// escapeMarkdown 转义强调、删除线、行内代码、链接、HTML 标签、行内公式和表格分隔符，
+// 单词内部的下划线保持不变。lineStart 为 true 时同时转义第一行行首的块级标记，换行后的行首总是会被转义。
+// following 为同一段落中紧随其后的 Markdown，用于判断 ~ 和 $ 能否配对、& 和 : 是否构成实体或 Emoji 别名
+func escapeMarkdown(text string, lineStart bool, following string) string {
+	// ~ 和 $ 只有在段落中之后还有同样的分隔符时才可能配对为删除线或公式，最后一个无需转义
+	lastTilde := strings.LastIndex(text, "~")
+	lastDollar := strings.LastIndex(text, "$")
+	followingTilde := hasDelimiter(following, '~')
+	followingDollar := hasDelimiter(following, '$')
+
+	lines := strings.Split(text, "\n")
+	offset := 0
+	for n, line := range lines {
+		buf := new(strings.Builder)
+		runes := []rune(line)
+		pos := offset
+		for j, r := range runes {
+			// rest 为同一行中当前字符及之后的内容，最后一行与之后的内容相连
+			rest := func() string {
+				if n == len(lines)-1 {
+					return string(runes[j:]) + following
+				}
+				return string(runes[j:])
+			}
+			switch {
+			case r == '_' && (j == 0 || j == len(runes)-1 || !isWordRune(runes[j-1]) || !isWordRune(runes[j+1])):
+				buf.WriteString(`\_`)
+			case r == '~' && (pos < lastTilde || followingTilde):
+				buf.WriteString(`\~`)
+			case r == '$' && (pos < lastDollar || followingDollar):
+				buf.WriteString(`\$`)
+			case r == ':' && isEmojiAlias(rest()):
+				buf.WriteString(`\:`)
+			case r == '&' && entityRegex.MatchString(rest()):
+				buf.WriteString(`\&`)
+			// lute 解析以 .@ 结尾的邮箱自动链接时会丢失字符，如 a.@
+			case r == '@' && j > 0 && runes[j-1] == '.' && !emailRegex.MatchString(rest()):
+				buf.WriteString(`\@`)
+			default:
+				buf.WriteString(markdownEscaper.Replace(string(r)))
+			}
+			pos += utf8.RuneLen(r)
+		}
+		offset += len(line) + 1
+		line = buf.String()
+
+		if n > 0 || lineStart {
+			content := strings.TrimLeft(line, " \t")
+			indent := line[:len(line)-len(content)]
+			if m := lineStartRegex.FindString(content); m != "" && content[0] != '\\' {
+				// 有序列表转义编号后的标点，其余转义第一个字符
+				if i := strings.IndexAny(m, ".)"); i > 0 && m[0] >= '0' && m[0] <= '9' {
+					content = content[:i] + `\` + content[i:]
+				} else {
+					content = `\` + content
+				}
+			}
+			line = indent + content
+		}
+		lines[n] = line
+	}
+	return strings.Join(lines, "\n")
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// isEmojiAlias 判断 text 是否以会被替换为 Emoji 的别名开头
+func isEmojiAlias(text string) bool {
+	m := emojiAliasRegex.FindStringSubmatch(text)
+	if m == nil {
+		return false
+	}
+	_, ok := parse.EmojiAliasUnicode[m[1]]
+	return ok
+}
+
+// hasDelimiter 判断 markdown 中是否有未被转义的分隔符
+func hasDelimiter(markdown string, delimiter rune) bool {
+	escaped := false
+	for _, r := range markdown {
+		if r == delimiter && !escaped {
+			return true
+		}
+		escaped = r == '\\' && !escaped
+	}
+	return false
+}
+
+// emitCode 输出代码块，代码中的文档链接等样式按行内节点输出，文本不做转义
+func (e *MarkdownEmitter) emitCode(b *Block) string {
+	if len(b.Inlines) == 0 {
+		return fencedCode(b.Attrs["language"], b.Text)
+	}
+	c := *e
+	c.inCode = true
+	return fencedCode(b.Attrs["language"], strings.TrimSpace(c.EmitInlines(b.Inlines)))
+}
+
+// fencedCode 输出代码块，内容中含有 ``` 时使用更长的反引号序列作为围栏
+func fencedCode(language, code string) string {
+	fence := "```"
+	for strings.Contains(code, fence) {
+		fence += "`"
+	}
+	return fence + language + "\n" + code + "\n" + fence + "\n"
+}
+
+// codeSpan 输出行内代码，内容中含有反引号时使用更长的反引号序列包裹
+func codeSpan(content string) string {
+	fence := "`"
+	for strings.Contains(content, fence) {
+		fence += "`"
+	}
+	if strings.HasPrefix(content, "`") || strings.HasSuffix(content, "`") {
+		content = " " + content + " "
+	}
+	return fence + content + fence
+}
+
+// emitMentionUser 按 mention_mode 输出被 @提及的用户，查询不到时输出原始 ID
+func (e *MarkdownEmitter) emitMentionUser(i *Inline) string {
+	user := UserInfo{Name: i.Attrs["name"], EnName: i.Attrs["en_name"], Email: i.Attrs["email"]}
+	openID := i.Attrs["user_id"]
+	if user.Name == "" {
+		return openID
+	}
+	switch e.mentionMode {
+	case MentionModeMailto:
+		if user.Email != "" {
+			return fmt.Sprintf("[@%s](mailto:%s)", user.Name, user.Email)
+		}
+	case MentionModeTemplate:
+		if e.mentionTemplate != nil {
+			buf := new(strings.Builder)
+			err := e.mentionTemplate.Execute(buf, struct {
+				UserInfo
+				OpenID string
+			}{user, openID})
+			if err == nil {
+				return buf.String()
+			}
+		}
+	}
+	return "@" + user.Name
+}
+
+// indentLines 为每个非空行增加一级列表缩进
+func indentLines(content string) string {
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = "\t" + line
+		}
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
@@ -0,0 +1,190 @@
+package core_test
+
+import (
+	"strings"
+	"testing"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/88250/lute"
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/parse"
+	"github.com/Wsine/feishu2md/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEscapeMarkdown(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected string
+	}{
+		{"plain 中文 text", "plain 中文 text"},
+		{"2 * 3 * 4", `2 \* 3 \* 4`},
+		{"snake_case_name", "snake_case_name"},
+		{"_private", `\_private`},
+		{"# not a heading", `\# not a heading`},
+		{"#hashtag", "#hashtag"},
+		{"1. not a list", `1\. not a list`},
+		{"2021. year", `2021\. year`},
+		{"3.14", "3.14"},
+		{"- dash", `\- dash`},
+		{"a - b", "a - b"},
+		{"> quote", `\> quote`},
+		{"a > b", "a > b"},
+		{"---", `\---`},
+		{"<div>tag</div>", `\<div>tag\</div>`},
+		{"a | b", `a \| b`},
+		{"[link](url)", `\[link\](url)`},
+		{"`code`", "\\`code\\`"},
+		{"cost $5 and $6", `cost \$5 and $6`},
+		{"$XDG_CONFIG_HOME", "$XDG_CONFIG_HOME"},
+		{"~strike~", `\~strike~`},
+		{"~/.config", "~/.config"},
+		{`C:\path`, `C:\\path`},
+		{"&amp; & more", `\&amp; & more`},
+		{"&#39; &#x27; a&b R&D;", `\&#39; \&#x27; a&b R\&D;`},
+		{"i@typora.io", "i@typora.io"},
+		{"a.@ b.@c", `a.\@ b.@c`},
+		{"10:30 :smile:", `10:30 \:smile:`},
+		{"a:b:c 12:34:56 :not_an_emoji:", `a\:b:c 12:34:56 :not_an_emoji:`},
+		{"line\n# heading", "line\n\\# heading"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			assert.Equal(t, tt.expected, core.EscapeMarkdown(tt.text))
+		})
+	}
+}
+
+func TestMarkdownEmitterEscape(t *testing.T) {
+	emitter := core.NewMarkdownEmitter(core.NewConfig("", "").Output)
+	tests := []struct {
+		name     string
+		inline   *core.Inline
+		expected string
+	}{
+		{"text", &core.Inline{Type: core.NodeText, Text: "1. a*b*"}, `1\. a\*b\*`},
+		{"bold", &core.Inline{Type: core.NodeText, Text: "# a_", Marks: []core.Mark{core.MarkBold}}, `**# a\_**`},
+		{"code", &core.Inline{Type: core.NodeText, Text: "a*b", Marks: []core.Mark{core.MarkCode}}, "`a*b`"},
+		{"code with backtick", &core.Inline{Type: core.NodeText, Text: "a`b", Marks: []core.Mark{core.MarkCode}}, "``a`b``"},
+		{"code starting with backtick", &core.Inline{Type: core.NodeText, Text: "`a", Marks: []core.Mark{core.MarkCode}}, "`` `a ``"},
+		{"link", &core.Inline{Type: core.NodeText, Text: "[x]", Attrs: map[string]string{"href": "https://example.com/a (1)"}},
+			`[\[x\]](https://example.com/a%20%281%29)`},
+		{"mention doc", &core.Inline{Type: core.NodeMentionDoc, Text: "a_b [draft]", Attrs: map[string]string{"href": "https://example.com"}},
+			`[a_b \[draft\]](https://example.com)`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, emitter.EmitInline(tt.inline, true))
+		})
+	}
+
+	// 只有位于行首的文本才需要转义块级标记
+	assert.Equal(t, "**a** - b", emitter.EmitInlines([]*core.Inline{
+		{Type: core.NodeText, Text: "a", Marks: []core.Mark{core.MarkBold}},
+		{Type: core.NodeText, Text: " - b"},
+	}))
+
+	// 分隔符可以与之后节点中的分隔符配对
+	assert.Equal(t, `cost \$5 and $x$`, emitter.EmitInlines([]*core.Inline{
+		{Type: core.NodeText, Text: "cost $5 and "},
+		{Type: core.NodeInlineFormula, Text: "x"},
+	}))
+	assert.Equal(t, `\~a ~~b~~ \:smile:`, emitter.EmitInlines([]*core.Inline{
+		{Type: core.NodeText, Text: "~a "},
+		{Type: core.NodeText, Text: "b", Marks: []core.Mark{core.MarkStrikethrough}},
+		{Type: core.NodeText, Text: " :smile"},
+		{Type: core.NodeText, Text: ":"},
+	}))
+}
+
+func TestMarkdownEmitterEscapeTable(t *testing.T) {
+	cell := func(text string) *core.Block {
+		return &core.Block{Type: core.NodeTableCell, Children: []*core.Block{
+			{Type: core.NodeParagraph, Inlines: []*core.Inline{
+				{Type: core.NodeText, Text: text},
+				{Type: core.NodeText, Text: "x|y", Marks: []core.Mark{core.MarkCode}},
+			}},
+		}}
+	}
+	table := &core.Block{Type: core.NodeTable, Attrs: map[string]string{"header_row": "true"}, Children: []*core.Block{
+		{Type: core.NodeTableRow, Children: []*core.Block{cell("a|b <c>")}},
+	}}
+
+	config := core.NewConfig("", "").Output
+	config.TableMode = core.TableModeGFM
+	assert.Contains(t, core.NewMarkdownEmitter(config).EmitBlock(table), "| a\\|b \\<c>`x\\|y` |")
+
+	// HTML 表格中的内容不会被解析为 Markdown，只做 HTML 转义
+	config.TableMode = core.TableModeHTML
+	assert.Contains(t, core.NewMarkdownEmitter(config).EmitBlock(table), "<td>a|b &lt;c&gt;`x|y`</td>")
+}
+
+// markdownText 解析 Markdown 并返回段落中的纯文本，内容被解析为段落和文本以外的节点时返回 false。
+// 邮箱和网址会被 GFM 自动识别为链接，链接文本与原文相同，视为纯文本
+func markdownText(markdown string) (string, bool) {
+	tree := parse.Parse("", []byte(markdown), lute.New().ParseOptions)
+	buf := new(strings.Builder)
+	ok := true
+	ast.Walk(tree.Root, func(n *ast.Node, entering bool) ast.WalkStatus {
+		if !entering {
+			return ast.WalkContinue
+		}
+		switch n.Type {
+		case ast.NodeDocument, ast.NodeParagraph, ast.NodeBackslash:
+		case ast.NodeText, ast.NodeBackslashContent:
+			buf.Write(n.Tokens)
+		case ast.NodeLink:
+			if text := n.ChildByType(ast.NodeLinkText); text != nil {
+				buf.Write(text.Tokens)
+			}
+			return ast.WalkSkipChildren
+		case ast.NodeSoftBreak:
+			buf.WriteString("\n")
+		default:
+			ok = false
+			return ast.WalkStop
+		}
+		return ast.WalkContinue
+	})
+	return buf.String(), ok
+}
+
+func FuzzEscapeMarkdown(f *testing.F) {
+	for _, seed := range []string{
+		"plain text", "2 * 3 * 4", "snake_case _emphasis_", "# heading", "1. item", "1) item",
+		"- item", "+ item", "> quote", "---", "===", "***", "<div>", "<http://example.com>",
+		"a | b", "[link](url)", "![image](src)", "`code`", "```", "~~strike~~", "$x$", "$$",
+		`back\slash`, "&amp; &#39;", "[^1]", "中文_强调_文字", "**bold**", "line\nbreak",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, text string) {
+		if !utf8.ValidString(text) || strings.ContainsFunc(text, func(r rune) bool {
+			// 控制字符、行首尾的空白和空行在 Markdown 中本来就无法保留
+			return unicode.IsControl(r) && r != '\n' || r == '\uFFFD'
+		}) {
+			t.Skip()
+		}
+		lines := strings.Split(text, "\n")
+		for _, line := range lines {
+			if strings.TrimSpace(line) == "" || strings.TrimSpace(line) != line {
+				t.Skip()
+			}
+			// lute 对不完整的 GFM 自动链接的处理会改变文本，如 www.a: 和 http://，与转义无关
+			if strings.Contains(line, "www.") || strings.Contains(line, "://") {
+				t.Skip()
+			}
+		}
+		// lute 处理段落末尾紧跟数字的 $ 时会把结尾的换行读入文本，如 0$0，与转义无关
+		if n := len(text); n >= 2 && text[n-2] == '$' && text[n-1] >= '0' && text[n-1] <= '9' {
+			t.Skip()
+		}
+
+		markdown := core.EscapeMarkdown(text)
+		parsed, ok := markdownText(markdown)
+		if !ok || parsed != text {
+			t.Errorf("text %q escaped as %q parsed as %q (plain: %v)", text, markdown, parsed, ok)
+		}
+	})
+}
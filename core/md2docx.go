@@ -0,0 +1,511 @@
+package core
+
+import (
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/88250/lute"
+	"github.com/88250/lute/ast"
+	"github.com/88250/lute/parse"
+	"github.com/chyroc/lark"
+)
+
+// UploadBlock 由 Markdown 转换得到、待创建的 Block。飞书的 Block 需要逐层创建，
+// 子 Block 在父 Block 创建并拿到 block_id 之后再创建
+type UploadBlock struct {
+	Block    *lark.DocxBlock
+	Children []*UploadBlock
+	// ImageSrc 图片在 Markdown 中的地址，先创建空的图片 Block，上传图片后再替换
+	ImageSrc string
+	// Cells 表格各单元格的内容，按行优先排列，与创建表格后返回的单元格一一对应
+	Cells [][]*UploadBlock
+}
+
+// calloutTypeEmojis admonition 类型对应的默认高亮块 emoji 和背景色
+var calloutTypeEmojis = map[string]struct {
+	emojiID         string
+	backgroundColor lark.DocxCalloutBackgroundColor
+}{
+	CalloutTypeNote:      {"memo", 5},
+	CalloutTypeTip:       {"bulb", 4},
+	CalloutTypeImportant: {"exclamation", 6},
+	CalloutTypeWarning:   {"warning", 2},
+	CalloutTypeCaution:   {"no_entry", 1},
+}
+
+// ParseMarkdown 使用 lute 解析 Markdown，返回文档标题和待创建的 Block。
+// 位于开头的一级标题作为文档标题，YAML front matter 会被忽略
+func ParseMarkdown(markdown string) (string, []*UploadBlock) {
+	engine := lute.New()
+	// 保留 :emoji: 短代码原文
+	engine.ParseOptions.Emoji = false
+	tree := parse.Parse("", []byte(markdown), engine.ParseOptions)
+
+	first := tree.Root.FirstChild
+	for first != nil && first.Type == ast.NodeYamlFrontMatter {
+		first = first.Next
+	}
+	title := ""
+	if first != nil && first.Type == ast.NodeHeading && first.HeadingLevel == 1 {
+		title = plainTextElements(convertInlines(first))
+		first = first.Next
+	}
+
+	var blocks []*UploadBlock
+	for n := first; n != nil; n = n.Next {
+		blocks = append(blocks, convertBlock(n)...)
+	}
+	return title, blocks
+}
+
+// headingBlockTypes 各级 Markdown 标题对应的 Block 类型
+var headingBlockTypes = []lark.DocxBlockType{
+	lark.DocxBlockTypeHeading1,
+	lark.DocxBlockTypeHeading2,
+	lark.DocxBlockTypeHeading3,
+	lark.DocxBlockTypeHeading4,
+	lark.DocxBlockTypeHeading5,
+	lark.DocxBlockTypeHeading6,
+}
+
+// convertBlock 将 lute 的块级节点转换为 Block，段落中的图片会拆分为单独的图片 Block
+func convertBlock(n *ast.Node) []*UploadBlock {
+	switch n.Type {
+	case ast.NodeHeading:
+		// Markdown 标题最多六级
+		level := min(max(n.HeadingLevel, 1), len(headingBlockTypes))
+		return []*UploadBlock{newTextUploadBlock(headingBlockTypes[level-1], convertInlines(n))}
+	case ast.NodeParagraph:
+		return convertParagraph(n)
+	case ast.NodeList:
+		return convertList(n)
+	case ast.NodeCodeBlock:
+		return []*UploadBlock{convertCodeBlock(n)}
+	case ast.NodeBlockquote:
+		return []*UploadBlock{convertBlockquote(n)}
+	case ast.NodeMathBlock:
+		// 单独成段的公式即为块级公式，与下载时的处理方式一致
+		content := ""
+		if c := n.ChildByType(ast.NodeMathBlockContent); c != nil {
+			content = string(c.Tokens)
+		}
+		return []*UploadBlock{{Block: &lark.DocxBlock{
+			BlockType: lark.DocxBlockTypeText,
+			Text: &lark.DocxBlockText{Elements: []*lark.DocxTextElement{
+				{Equation: &lark.DocxTextElementEquation{Content: content}},
+			}},
+		}}}
+	case ast.NodeThematicBreak:
+		return []*UploadBlock{{Block: &lark.DocxBlock{BlockType: lark.DocxBlockTypeDivider}}}
+	case ast.NodeTable:
+		return []*UploadBlock{convertTable(n)}
+	case ast.NodeHTMLBlock:
+		// HTML 无法对应到 Block，保留原文以免丢失内容
+		return []*UploadBlock{newTextUploadBlock(lark.DocxBlockTypeText, []*lark.DocxTextElement{
+			textElement(strings.TrimRight(string(n.Tokens), "\n"), lark.DocxTextElementStyle{}),
+		})}
+	}
+	return nil
+}
+
+// convertParagraph 转换段落，图片按出现的位置拆分为图片 Block
+func convertParagraph(n *ast.Node) []*UploadBlock {
+	var blocks []*UploadBlock
+	c := &inlineConverter{}
+	flush := func() {
+		if elements := trimElements(c.elements); len(elements) > 0 {
+			blocks = append(blocks, newTextUploadBlock(lark.DocxBlockTypeText, elements))
+		}
+		c.elements = nil
+	}
+	for child := n.FirstChild; child != nil; child = child.Next {
+		if child.Type != ast.NodeImage {
+			c.convert(child, lark.DocxTextElementStyle{})
+			continue
+		}
+		flush()
+		src := ""
+		if dest := child.ChildByType(ast.NodeLinkDest); dest != nil {
+			src = string(dest.Tokens)
+		}
+		blocks = append(blocks, &UploadBlock{
+			Block:    &lark.DocxBlock{BlockType: lark.DocxBlockTypeImage, Image: &lark.DocxBlockImage{}},
+			ImageSrc: src,
+		})
+	}
+	flush()
+	return blocks
+}
+
+// convertList 将列表项转换为无序、有序或待办 Block，列表项中除首个段落外的内容作为子 Block
+func convertList(n *ast.Node) []*UploadBlock {
+	var blocks []*UploadBlock
+	for item := n.FirstChild; item != nil; item = item.Next {
+		if item.Type != ast.NodeListItem {
+			continue
+		}
+		blockType := lark.DocxBlockTypeBullet
+		if n.ListData != nil && n.ListData.Typ == 1 {
+			blockType = lark.DocxBlockTypeOrdered
+		}
+		var elements []*lark.DocxTextElement
+		var children []*UploadBlock
+		done := false
+		content := item.FirstChild
+		if content != nil && content.Type == ast.NodeParagraph {
+			if marker := content.ChildByType(ast.NodeTaskListItemMarker); marker != nil {
+				blockType = lark.DocxBlockTypeTodo
+				done = marker.TaskListItemChecked
+			}
+			// 段落中的图片放到列表项的子 Block 中
+			paragraph := convertParagraph(content)
+			if len(paragraph) > 0 && paragraph[0].Block.BlockType == lark.DocxBlockTypeText {
+				elements = paragraph[0].Block.Text.Elements
+				paragraph = paragraph[1:]
+			}
+			children = append(children, paragraph...)
+			content = content.Next
+		}
+		for ; content != nil; content = content.Next {
+			children = append(children, convertBlock(content)...)
+		}
+		if len(elements) == 0 {
+			elements = []*lark.DocxTextElement{textElement("", lark.DocxTextElementStyle{})}
+		}
+		b := newTextUploadBlock(blockType, elements)
+		if blockType == lark.DocxBlockTypeTodo {
+			b.Block.Todo.Style = &lark.DocxTextStyle{Done: done}
+		}
+		b.Children = children
+		blocks = append(blocks, b)
+	}
+	return blocks
+}
+
+func convertCodeBlock(n *ast.Node) *UploadBlock {
+	language := ""
+	if info := n.ChildByType(ast.NodeCodeBlockFenceInfoMarker); info != nil {
+		if fields := strings.Fields(string(info.CodeBlockInfo)); len(fields) > 0 {
+			language = strings.ToLower(fields[0])
+		}
+	}
+	code := ""
+	if c := n.ChildByType(ast.NodeCodeBlockCode); c != nil {
+		code = strings.TrimSuffix(string(c.Tokens), "\n")
+	}
+	b := newTextUploadBlock(lark.DocxBlockTypeCode, []*lark.DocxTextElement{
+		textElement(code, lark.DocxTextElementStyle{}),
+	})
+	b.Block.Code.Style = &lark.DocxTextStyle{Language: mdStr2DocxCodeLang(language)}
+	return b
+}
+
+// mdStr2DocxCodeLang 按代码块的语言标识查找飞书的代码语言，未收录的语言视为纯文本
+func mdStr2DocxCodeLang(language string) lark.DocxCodeLanguage {
+	if language == "" {
+		return lark.DocxCodeLanguagePlainText
+	}
+	for lang, name := range DocxCodeLang2MdStr {
+		if name == language {
+			return lang
+		}
+	}
+	return lark.DocxCodeLanguagePlainText
+}
+
+// convertBlockquote 将引用转换为引用容器，以 [!TYPE] 开头的 admonition 转换为高亮块
+func convertBlockquote(n *ast.Node) *UploadBlock {
+	var children []*UploadBlock
+	for child := n.FirstChild; child != nil; child = child.Next {
+		children = append(children, convertBlock(child)...)
+	}
+
+	if len(children) > 0 && children[0].Block.BlockType == lark.DocxBlockTypeText {
+		if callout, rest := convertCalloutHeader(children[0].Block.Text.Elements); callout != nil {
+			if len(rest) > 0 {
+				children[0].Block.Text.Elements = rest
+			} else {
+				children = children[1:]
+			}
+			return &UploadBlock{
+				Block:    &lark.DocxBlock{BlockType: lark.DocxBlockTypeCallout, Callout: callout},
+				Children: children,
+			}
+		}
+	}
+	return &UploadBlock{
+		Block:    &lark.DocxBlock{BlockType: lark.DocxBlockTypeQuoteContainer, QuoteContainer: &lark.DocxBlocQuoteContainer{}},
+		Children: children,
+	}
+}
+
+// convertCalloutHeader 解析 admonition 的首行，返回高亮块属性和首行之后的文本
+func convertCalloutHeader(elements []*lark.DocxTextElement) (*lark.DocxBlockCallout, []*lark.DocxTextElement) {
+	if len(elements) == 0 || elements[0].TextRun == nil {
+		return nil, nil
+	}
+	content := elements[0].TextRun.Content
+	if !strings.HasPrefix(content, "[!") {
+		return nil, nil
+	}
+	end := strings.Index(content, "]")
+	if end < 0 {
+		return nil, nil
+	}
+	calloutType := strings.ToUpper(content[2:end])
+	defaults, ok := calloutTypeEmojis[calloutType]
+	if !ok {
+		return nil, nil
+	}
+	callout := &lark.DocxBlockCallout{EmojiID: defaults.emojiID, BackgroundColor: defaults.backgroundColor}
+
+	header, rest, _ := strings.Cut(content[end+1:], "\n")
+	if emoji := strings.TrimSpace(header); emoji != "" && emoji != calloutEmojis[callout.EmojiID] {
+		callout.EmojiID = calloutEmojiID(emoji, callout.EmojiID)
+	}
+
+	remaining := elements[1:]
+	if rest != "" {
+		first := *elements[0].TextRun
+		first.Content = rest
+		remaining = append([]*lark.DocxTextElement{{TextRun: &first}}, remaining...)
+	}
+	return callout, trimElements(remaining)
+}
+
+// calloutEmojiID 查找 emoji 对应的 emoji_id，兼容下载时输出的 :emoji_id: 短代码，未收录时返回 fallback
+func calloutEmojiID(emoji, fallback string) string {
+	if len(emoji) > 2 && strings.HasPrefix(emoji, ":") && strings.HasSuffix(emoji, ":") {
+		return strings.Trim(emoji, ":")
+	}
+	ids := make([]string, 0, len(calloutEmojis))
+	for id := range calloutEmojis {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		if calloutEmojis[id] == emoji {
+			return id
+		}
+	}
+	return fallback
+}
+
+// convertTable 转换 GFM 表格，每个单元格的内容为一个文本 Block
+func convertTable(n *ast.Node) *UploadBlock {
+	var rows []*ast.Node
+	for child := n.FirstChild; child != nil; child = child.Next {
+		switch child.Type {
+		case ast.NodeTableHead:
+			for row := child.FirstChild; row != nil; row = row.Next {
+				rows = append(rows, row)
+			}
+		case ast.NodeTableRow:
+			rows = append(rows, child)
+		}
+	}
+
+	// 列数取所有行中最多的单元格数，飞书按行优先顺序返回 RowSize × ColumnSize 个单元格
+	columns := 0
+	rowCells := make([][][]*UploadBlock, len(rows))
+	for r, row := range rows {
+		for cell := row.FirstChild; cell != nil; cell = cell.Next {
+			if cell.Type == ast.NodeTableCell {
+				rowCells[r] = append(rowCells[r], convertParagraph(cell))
+			}
+		}
+		columns = max(columns, len(rowCells[r]))
+	}
+	var cells [][]*UploadBlock
+	for _, row := range rowCells {
+		cells = append(cells, row...)
+		// 列数不足的行以空单元格补齐
+		for i := len(row); i < columns; i++ {
+			cells = append(cells, nil)
+		}
+	}
+	return &UploadBlock{
+		Block: &lark.DocxBlock{
+			BlockType: lark.DocxBlockTypeTable,
+			Table: &lark.DocxBlockTable{Property: &lark.DocxBlockTableProperty{
+				RowSize:    int64(len(rows)),
+				ColumnSize: int64(columns),
+			}},
+		},
+		Cells: cells,
+	}
+}
+
+// inlineConverter 将 lute 的行内节点转换为飞书的文本元素，样式相同的相邻文本会被合并
+type inlineConverter struct {
+	elements  []*lark.DocxTextElement
+	underline bool // 位于 <u></u> 之间
+}
+
+func convertInlines(n *ast.Node) []*lark.DocxTextElement {
+	c := &inlineConverter{}
+	for child := n.FirstChild; child != nil; child = child.Next {
+		c.convert(child, lark.DocxTextElementStyle{})
+	}
+	return trimElements(c.elements)
+}
+
+func (c *inlineConverter) convert(n *ast.Node, style lark.DocxTextElementStyle) {
+	switch n.Type {
+	case ast.NodeText, ast.NodeLinkText, ast.NodeHTMLEntity, ast.NodeBackslashContent:
+		c.text(string(n.Tokens), style)
+	case ast.NodeCodeSpan:
+		style.InlineCode = true
+		if content := n.ChildByType(ast.NodeCodeSpanContent); content != nil {
+			c.text(string(content.Tokens), style)
+		}
+	case ast.NodeInlineMath:
+		if content := n.ChildByType(ast.NodeInlineMathContent); content != nil {
+			c.elements = append(c.elements, &lark.DocxTextElement{
+				Equation: &lark.DocxTextElementEquation{Content: string(content.Tokens)},
+			})
+		}
+	case ast.NodeSoftBreak, ast.NodeHardBreak:
+		c.text("\n", style)
+	case ast.NodeInlineHTML:
+		switch strings.ToLower(string(n.Tokens)) {
+		case "<u>":
+			c.underline = true
+		case "</u>":
+			c.underline = false
+		case "<br>", "<br/>", "<br />":
+			c.text("\n", style)
+		default:
+			c.text(string(n.Tokens), style)
+		}
+	case ast.NodeImage:
+		// 行内图片只出现在标题等无法拆分的位置，保留为指向图片的链接
+		if dest := n.ChildByType(ast.NodeLinkDest); dest != nil {
+			style.Link = &lark.DocxTextElementStyleLink{URL: url.QueryEscape(string(dest.Tokens))}
+		}
+		c.children(n, style)
+	case ast.NodeEmphasis:
+		style.Italic = true
+		c.children(n, style)
+	case ast.NodeStrong:
+		style.Bold = true
+		c.children(n, style)
+	case ast.NodeStrikethrough:
+		style.Strikethrough = true
+		c.children(n, style)
+	case ast.NodeLink:
+		if dest := n.ChildByType(ast.NodeLinkDest); dest != nil {
+			style.Link = &lark.DocxTextElementStyleLink{URL: url.QueryEscape(string(dest.Tokens))}
+		}
+		c.children(n, style)
+	case ast.NodeBackslash, ast.NodeTableCell, ast.NodeParagraph:
+		c.children(n, style)
+	}
+}
+
+func (c *inlineConverter) children(n *ast.Node, style lark.DocxTextElementStyle) {
+	for child := n.FirstChild; child != nil; child = child.Next {
+		c.convert(child, style)
+	}
+}
+
+func (c *inlineConverter) text(content string, style lark.DocxTextElementStyle) {
+	if content == "" {
+		return
+	}
+	style.Underline = style.Underline || c.underline
+	if last := len(c.elements) - 1; last >= 0 && c.elements[last].TextRun != nil {
+		prev := c.elements[last].TextRun
+		prevStyle := lark.DocxTextElementStyle{}
+		if prev.TextElementStyle != nil {
+			prevStyle = *prev.TextElementStyle
+		}
+		if reflect.DeepEqual(prevStyle, style) {
+			prev.Content += content
+			return
+		}
+	}
+	c.elements = append(c.elements, textElement(content, style))
+}
+
+func textElement(content string, style lark.DocxTextElementStyle) *lark.DocxTextElement {
+	tr := &lark.DocxTextElementTextRun{Content: content}
+	if style != (lark.DocxTextElementStyle{}) {
+		tr.TextElementStyle = &style
+	}
+	return &lark.DocxTextElement{TextRun: tr}
+}
+
+// trimElements 去掉首尾的空白，只剩空白时返回 nil
+func trimElements(elements []*lark.DocxTextElement) []*lark.DocxTextElement {
+	for len(elements) > 0 {
+		first := elements[0].TextRun
+		if first == nil {
+			break
+		}
+		if first.Content = strings.TrimLeft(first.Content, " \t\n"); first.Content != "" {
+			break
+		}
+		elements = elements[1:]
+	}
+	for len(elements) > 0 {
+		last := elements[len(elements)-1].TextRun
+		if last == nil {
+			break
+		}
+		if last.Content = strings.TrimRight(last.Content, " \t\n"); last.Content != "" {
+			break
+		}
+		elements = elements[:len(elements)-1]
+	}
+	if len(elements) == 0 {
+		return nil
+	}
+	return elements
+}
+
+func plainTextElements(elements []*lark.DocxTextElement) string {
+	buf := new(strings.Builder)
+	for _, e := range elements {
+		switch {
+		case e.TextRun != nil:
+			buf.WriteString(e.TextRun.Content)
+		case e.Equation != nil:
+			buf.WriteString(e.Equation.Content)
+		}
+	}
+	return buf.String()
+}
+
+// newTextUploadBlock 创建文本类的 Block，文本放在与 blockType 对应的字段中
+func newTextUploadBlock(blockType lark.DocxBlockType, elements []*lark.DocxTextElement) *UploadBlock {
+	text := &lark.DocxBlockText{Elements: elements}
+	b := &lark.DocxBlock{BlockType: blockType}
+	switch blockType {
+	case lark.DocxBlockTypeText:
+		b.Text = text
+	case lark.DocxBlockTypeHeading1:
+		b.Heading1 = text
+	case lark.DocxBlockTypeHeading2:
+		b.Heading2 = text
+	case lark.DocxBlockTypeHeading3:
+		b.Heading3 = text
+	case lark.DocxBlockTypeHeading4:
+		b.Heading4 = text
+	case lark.DocxBlockTypeHeading5:
+		b.Heading5 = text
+	case lark.DocxBlockTypeHeading6:
+		b.Heading6 = text
+	case lark.DocxBlockTypeBullet:
+		b.Bullet = text
+	case lark.DocxBlockTypeOrdered:
+		b.Ordered = text
+	case lark.DocxBlockTypeTodo:
+		b.Todo = text
+	case lark.DocxBlockTypeCode:
+		b.Code = text
+	}
+	return &UploadBlock{Block: b}
+}
@@ -0,0 +1,44 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/88250/lute/ast"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertTableWiderRow(t *testing.T) {
+	// lute 会截断比表头宽的行，这里直接构建表格节点
+	row := func(texts ...string) *ast.Node {
+		r := &ast.Node{Type: ast.NodeTableRow}
+		for _, text := range texts {
+			cell := &ast.Node{Type: ast.NodeTableCell}
+			cell.AppendChild(&ast.Node{Type: ast.NodeText, Tokens: []byte(text)})
+			r.AppendChild(cell)
+		}
+		return r
+	}
+	table := &ast.Node{Type: ast.NodeTable}
+	head := &ast.Node{Type: ast.NodeTableHead}
+	head.AppendChild(row("a"))
+	table.AppendChild(head)
+	table.AppendChild(row("1"))
+	table.AppendChild(row("2", "3", "4"))
+
+	// 较宽的行在后面时，之前的行同样补齐到最终的列数
+	b := convertTable(table)
+	assert.Equal(t, int64(3), b.Block.Table.Property.RowSize)
+	assert.Equal(t, int64(3), b.Block.Table.Property.ColumnSize)
+	assert.Len(t, b.Cells, 9)
+	cellText := func(i int) string {
+		if b.Cells[i] == nil {
+			return ""
+		}
+		return b.Cells[i][0].Block.Text.Elements[0].TextRun.Content
+	}
+	var texts []string
+	for i := range b.Cells {
+		texts = append(texts, cellText(i))
+	}
+	assert.Equal(t, []string{"a", "", "", "1", "", "", "2", "3", "4"}, texts)
+}
@@ -0,0 +1,99 @@
+package core_test
+
+import (
+	"testing"
+
+	"github.com/Wsine/feishu2md/core"
+	"github.com/chyroc/lark"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMarkdown(t *testing.T) {
+	title, blocks := core.ParseMarkdown("---\ntitle: 忽略\n---\n" +
+		"# 上传\n\n" +
+		"## 概述\n\n" +
+		"见 **[文档](https://example.com/a?b=1)** 和 `code`，<u>下划线</u> $E=mc^2$\n\n" +
+		"- [x] 完成\n" +
+		"- 步骤\n" +
+		"  1. 安装\n\n" +
+		"```go\nfmt.Println()\n```\n\n" +
+		"前文 ![图](static/a.png) 后文\n\n" +
+		"| a | b |\n| --- | --- |\n| 1 |\n\n" +
+		"> [!WARNING] 🚧\n> 小心\n\n" +
+		"> 引用\n\n" +
+		"---\n")
+	assert.Equal(t, "上传", title)
+	assert.Len(t, blocks, 12)
+
+	assert.Equal(t, lark.DocxBlockTypeHeading2, blocks[0].Block.BlockType)
+	assert.Equal(t, "概述", blocks[0].Block.Heading2.Elements[0].TextRun.Content)
+
+	elements := blocks[1].Block.Text.Elements
+	assert.Len(t, elements, 8)
+	assert.Equal(t, "文档", elements[1].TextRun.Content)
+	assert.True(t, elements[1].TextRun.TextElementStyle.Bold)
+	assert.Equal(t, "https%3A%2F%2Fexample.com%2Fa%3Fb%3D1", elements[1].TextRun.TextElementStyle.Link.URL)
+	assert.True(t, elements[3].TextRun.TextElementStyle.InlineCode)
+	assert.Equal(t, "下划线", elements[5].TextRun.Content)
+	assert.True(t, elements[5].TextRun.TextElementStyle.Underline)
+	assert.Equal(t, "E=mc^2", elements[7].Equation.Content)
+
+	assert.Equal(t, lark.DocxBlockTypeTodo, blocks[2].Block.BlockType)
+	assert.True(t, blocks[2].Block.Todo.Style.Done)
+	assert.Equal(t, "完成", blocks[2].Block.Todo.Elements[0].TextRun.Content)
+	assert.Equal(t, lark.DocxBlockTypeBullet, blocks[3].Block.BlockType)
+	assert.Len(t, blocks[3].Children, 1)
+	assert.Equal(t, lark.DocxBlockTypeOrdered, blocks[3].Children[0].Block.BlockType)
+
+	assert.Equal(t, lark.DocxCodeLanguageGo, blocks[4].Block.Code.Style.Language)
+	assert.Equal(t, "fmt.Println()", blocks[4].Block.Code.Elements[0].TextRun.Content)
+
+	// 段落中的图片拆分为单独的图片 Block
+	assert.Equal(t, "前文", blocks[5].Block.Text.Elements[0].TextRun.Content)
+	assert.Equal(t, lark.DocxBlockTypeImage, blocks[6].Block.BlockType)
+	assert.Equal(t, "static/a.png", blocks[6].ImageSrc)
+	assert.Equal(t, "后文", blocks[7].Block.Text.Elements[0].TextRun.Content)
+
+	// 缺少的单元格以空单元格补齐
+	table := blocks[8]
+	assert.Equal(t, int64(2), table.Block.Table.Property.RowSize)
+	assert.Equal(t, int64(2), table.Block.Table.Property.ColumnSize)
+	assert.Len(t, table.Cells, 4)
+	assert.Nil(t, table.Cells[3])
+
+	callout := blocks[9]
+	assert.Equal(t, lark.DocxBlockTypeCallout, callout.Block.BlockType)
+	assert.Equal(t, "construction", callout.Block.Callout.EmojiID)
+	assert.Equal(t, "小心", callout.Children[0].Block.Text.Elements[0].TextRun.Content)
+
+	assert.Equal(t, lark.DocxBlockTypeQuoteContainer, blocks[10].Block.BlockType)
+	assert.Equal(t, lark.DocxBlockTypeDivider, blocks[11].Block.BlockType)
+}
+
+func TestParseMarkdownTitle(t *testing.T) {
+	// 不以一级标题开头时标题为空，由调用方决定
+	title, blocks := core.ParseMarkdown("正文\n\n# 标题\n")
+	assert.Equal(t, "", title)
+	assert.Len(t, blocks, 2)
+	assert.Equal(t, lark.DocxBlockTypeHeading1, blocks[1].Block.BlockType)
+}
+
+func TestParseMarkdownTableWidth(t *testing.T) {
+	// 比表头更宽的行按 GFM 截断，单元格数与行列数一致
+	_, blocks := core.ParseMarkdown("| a |\n| --- |\n| 1 |\n| 2 | 3 | 4 |\n")
+	assert.Len(t, blocks, 1)
+	table := blocks[0]
+	assert.Equal(t, int64(3), table.Block.Table.Property.RowSize)
+	assert.Equal(t, int64(1), table.Block.Table.Property.ColumnSize)
+	assert.Len(t, table.Cells, 3)
+	assert.Equal(t, "2", table.Cells[2][0].Block.Text.Elements[0].TextRun.Content)
+}
+
+func TestParseMarkdownHeadingLevels(t *testing.T) {
+	_, blocks := core.ParseMarkdown("### 三级\n\n###### 六级\n")
+	assert.Len(t, blocks, 2)
+	assert.Equal(t, lark.DocxBlockTypeHeading3, blocks[0].Block.BlockType)
+	assert.Equal(t, "三级", blocks[0].Block.Heading3.Elements[0].TextRun.Content)
+	assert.Equal(t, lark.DocxBlockTypeHeading6, blocks[1].Block.BlockType)
+	assert.Equal(t, "六级", blocks[1].Block.Heading6.Elements[0].TextRun.Content)
+}
@@ -1,29 +1,130 @@
 package core
 
 import (
+	"bytes"
+	"context"
+	"encoding/csv"
 	"fmt"
 	"reflect"
+	"strconv"
 	"strings"
+	"time"
 
-	"github.com/Wsine/feishu2md/utils"
 	"github.com/chyroc/lark"
 	"github.com/olekukonko/tablewriter"
 )
 
+// 内嵌电子表格默认的最大渲染行列数
+const (
+	DefaultSheetMaxRows = 100
+	DefaultSheetMaxCols = 20
+)
+
+// EmbedFetcher 拉取文档中内嵌内容（如电子表格）的数据，*Client 实现了该接口
+type EmbedFetcher interface {
+	GetSheetData(ctx context.Context, spreadsheetToken, sheetID string, maxRows, maxCols int) (*SheetData, error)
+	GetBitableData(ctx context.Context, appToken, tableID string) (*BitableData, error)
+}
+
+// SidecarFile 需要写入到 Markdown 文件同一目录下的附属文件
+type SidecarFile struct {
+	Name    string
+	Content []byte
+}
+
 type Parser struct {
-	useHTMLTags bool
-	ImgTokens   []string
-	blockMap    map[string]*lark.DocxBlock
+	useHTMLTags  bool
+	sheetMaxRows int
+	sheetMaxCols int
+	bitableMode  string
+	toc          bool
+	ImgTokens    []string
+	FileTokens   []string
+	BoardBlocks  []string
+	Sidecars     []*SidecarFile
+	Diagnostics  []*Diagnostic
+	blockMap     map[string]*lark.DocxBlock
+
+	textColors        map[int]string
+	backgroundColors  map[int]string
+	calloutEmojiTypes map[string]string
+	calloutColorTypes map[int]string
+
+	ctx     context.Context
+	fetcher EmbedFetcher
+	baseURL string
+
+	documentID  string
+	properties  DocxBlockProperties
+	listNumbers map[string]int
+
+	headingAnchors bool
+	anchors        map[string]string // 标题的 Block ID 到锚点的映射
+
+	userResolver  UserResolver
+	assetResolver AssetResolver
+	users         map[string]*UserInfo
+	md            *MarkdownEmitter
+
+	renderers        map[lark.DocxBlockType]BlockRenderer
+	fallbackRenderer BlockRenderer
 }
 
 func NewParser(config OutputConfig) *Parser {
+	sheetMaxRows := config.SheetMaxRows
+	if sheetMaxRows <= 0 {
+		sheetMaxRows = DefaultSheetMaxRows
+	}
+	sheetMaxCols := config.SheetMaxCols
+	if sheetMaxCols <= 0 {
+		sheetMaxCols = DefaultSheetMaxCols
+	}
 	return &Parser{
-		useHTMLTags: config.UseHTMLTags,
-		ImgTokens:   make([]string, 0),
-		blockMap:    make(map[string]*lark.DocxBlock),
+		useHTMLTags:  config.UseHTMLTags,
+		sheetMaxRows: sheetMaxRows,
+		sheetMaxCols: sheetMaxCols,
+		bitableMode:  config.BitableMode,
+		toc:          config.TOC,
+		ImgTokens:    make([]string, 0),
+		FileTokens:   make([]string, 0),
+		BoardBlocks:  make([]string, 0),
+		Sidecars:     make([]*SidecarFile, 0),
+		blockMap:     make(map[string]*lark.DocxBlock),
+		ctx:          context.Background(),
+
+		textColors:        mergeDefaults(DefaultTextColors, config.TextColors),
+		backgroundColors:  mergeDefaults(DefaultBackgroundColors, config.BackgroundColors),
+		calloutEmojiTypes: mergeDefaults(DefaultCalloutEmojiTypes, config.CalloutEmojiTypes),
+		calloutColorTypes: mergeDefaults(DefaultCalloutColorTypes, config.CalloutColorTypes),
+		headingAnchors:    config.HeadingAnchors,
+		users:             make(map[string]*UserInfo),
+		md:                NewMarkdownEmitter(config),
+		renderers:         copyBlockRenderers(),
+		fallbackRenderer:  UnsupportedBlockRenderer,
 	}
 }
 
+// SetEmbedFetcher 设置用于拉取内嵌内容的数据源，未设置时内嵌内容仅输出为链接
+func (p *Parser) SetEmbedFetcher(ctx context.Context, fetcher EmbedFetcher) {
+	p.ctx = ctx
+	p.fetcher = fetcher
+}
+
+// SetBlockProperties 设置 Client.GetDocxContent 返回的 Block 属性，如表格的标题行
+func (p *Parser) SetBlockProperties(properties DocxBlockProperties) {
+	p.properties = properties
+}
+
+// SetUserResolver 设置用于查询 @提及用户姓名的数据源，未设置时输出用户的原始 ID
+func (p *Parser) SetUserResolver(resolver UserResolver) {
+	p.userResolver = resolver
+}
+
+// SetBaseURL 设置文档所在站点的地址（如 https://sample.feishu.cn），用于生成内嵌内容的原始链接
+func (p *Parser) SetBaseURL(baseURL string) {
+	p.baseURL = strings.TrimSuffix(baseURL, "/")
+}
+
 // =============================================================
 // Parser utils
 // =============================================================
@@ -98,6 +199,28 @@ var DocxCodeLang2MdStr = map[lark.DocxCodeLanguage]string{
 	lark.DocxCodeLanguageYAML:         "yaml",
 }
 
+// escapeTableCell 转义单元格中会破坏 Markdown 表格结构的字符，已经转义过的 \| 保持不变
+func escapeTableCell(content string) string {
+	buf := new(strings.Builder)
+	backslashes := 0
+	for _, r := range content {
+		switch {
+		case r == '|' && backslashes%2 == 0:
+			buf.WriteString("\\|")
+		case r == '\n':
+			buf.WriteString("<br/>")
+		default:
+			buf.WriteRune(r)
+		}
+		if r == '\\' {
+			backslashes++
+		} else {
+			backslashes = 0
+		}
+	}
+	return buf.String()
+}
+
 func renderMarkdownTable(data [][]string) string {
 	builder := &strings.Builder{}
 	table := tablewriter.NewWriter(builder)
@@ -116,383 +239,416 @@ func renderMarkdownTable(data [][]string) string {
 // Parse the new version of document (docx)
 // =============================================================
 
+// ParseDocxContent 将文档输出为 Markdown，与 HTML 一样由 BuildDocument 构建的 AST 输出
 func (p *Parser) ParseDocxContent(doc *lark.DocxDocument, blocks []*lark.DocxBlock) string {
+	return p.md.Emit(p.BuildDocument(doc, blocks))
+}
+
+// loadBlocks 建立 Block 索引，查询文档中被 @提及的用户并生成标题锚点
+func (p *Parser) loadBlocks(doc *lark.DocxDocument, blocks []*lark.DocxBlock) {
 	for _, block := range blocks {
 		p.blockMap[block.BlockID] = block
 	}
-
-	entryBlock := p.blockMap[doc.DocumentID]
-	return p.ParseDocxBlock(entryBlock, 0)
+	p.documentID = doc.DocumentID
+	p.resolveMentionUsers(blocks)
+	p.loadHeadings()
 }
 
-func (p *Parser) ParseDocxBlock(b *lark.DocxBlock, indentLevel int) string {
-	buf := new(strings.Builder)
-	buf.WriteString(strings.Repeat("\t", indentLevel))
-	switch b.BlockType {
-	case lark.DocxBlockTypePage:
-		buf.WriteString(p.ParseDocxBlockPage(b))
-	case lark.DocxBlockTypeText:
-		buf.WriteString(p.ParseDocxBlockText(b.Text))
-	case lark.DocxBlockTypeCallout:
-		buf.WriteString(p.ParseDocxBlockCallout(b))
-	case lark.DocxBlockTypeHeading1:
-		buf.WriteString(p.ParseDocxBlockHeading(b, 1))
-	case lark.DocxBlockTypeHeading2:
-		buf.WriteString(p.ParseDocxBlockHeading(b, 2))
-	case lark.DocxBlockTypeHeading3:
-		buf.WriteString(p.ParseDocxBlockHeading(b, 3))
-	case lark.DocxBlockTypeHeading4:
-		buf.WriteString(p.ParseDocxBlockHeading(b, 4))
-	case lark.DocxBlockTypeHeading5:
-		buf.WriteString(p.ParseDocxBlockHeading(b, 5))
-	case lark.DocxBlockTypeHeading6:
-		buf.WriteString(p.ParseDocxBlockHeading(b, 6))
-	case lark.DocxBlockTypeHeading7:
-		buf.WriteString(p.ParseDocxBlockHeading(b, 7))
-	case lark.DocxBlockTypeHeading8:
-		buf.WriteString(p.ParseDocxBlockHeading(b, 8))
-	case lark.DocxBlockTypeHeading9:
-		buf.WriteString(p.ParseDocxBlockHeading(b, 9))
-	case lark.DocxBlockTypeBullet:
-		buf.WriteString(p.ParseDocxBlockBullet(b, indentLevel))
-	case lark.DocxBlockTypeOrdered:
-		buf.WriteString(p.ParseDocxBlockOrdered(b, indentLevel))
-	case lark.DocxBlockTypeCode:
-		buf.WriteString("```" + DocxCodeLang2MdStr[b.Code.Style.Language] + "\n")
-		buf.WriteString(strings.TrimSpace(p.ParseDocxBlockText(b.Code)))
-		buf.WriteString("\n```\n")
-	case lark.DocxBlockTypeQuote:
-		buf.WriteString("> ")
-		buf.WriteString(p.ParseDocxBlockText(b.Quote))
-	case lark.DocxBlockTypeEquation:
-		buf.WriteString("$$\n")
-		buf.WriteString(p.ParseDocxBlockText(b.Equation))
-		buf.WriteString("\n$$\n")
-	case lark.DocxBlockTypeTodo:
-		if b.Todo.Style.Done {
-			buf.WriteString("- [x] ")
-		} else {
-			buf.WriteString("- [ ] ")
+// resolveMentionUsers 一次性查询文档中所有被 @提及的用户，查询失败时保留原始 ID
+func (p *Parser) resolveMentionUsers(blocks []*lark.DocxBlock) {
+	if p.userResolver == nil {
+		return
+	}
+	seen := map[string]bool{}
+	var openIDs []string
+	for _, b := range blocks {
+		v := reflect.ValueOf(b).Elem()
+		for i := 0; i < v.NumField(); i++ {
+			text, ok := v.Field(i).Interface().(*lark.DocxBlockText)
+			if !ok || text == nil {
+				continue
+			}
+			for _, e := range text.Elements {
+				if e.MentionUser != nil && !seen[e.MentionUser.UserID] {
+					seen[e.MentionUser.UserID] = true
+					openIDs = append(openIDs, e.MentionUser.UserID)
+				}
+			}
 		}
-		buf.WriteString(p.ParseDocxBlockText(b.Todo))
-	case lark.DocxBlockTypeDivider:
-		buf.WriteString("---\n")
-	case lark.DocxBlockTypeImage:
-		buf.WriteString(p.ParseDocxBlockImage(b.Image))
-	case lark.DocxBlockTypeTableCell:
-		buf.WriteString(p.ParseDocxBlockTableCell(b))
-	case lark.DocxBlockTypeTable:
-		buf.WriteString(p.ParseDocxBlockTable(b.Table))
-	case lark.DocxBlockTypeQuoteContainer:
-		buf.WriteString(p.ParseDocxBlockQuoteContainer(b))
-	case lark.DocxBlockTypeGrid:
-		buf.WriteString(p.ParseDocxBlockGrid(b, indentLevel))
-	default:
 	}
-	return buf.String()
+	if len(openIDs) == 0 {
+		return
+	}
+	users, _ := p.userResolver.BatchGetUsers(p.ctx, openIDs)
+	for openID, user := range users {
+		p.users[openID] = user
+	}
 }
 
-func (p *Parser) ParseDocxBlockPage(b *lark.DocxBlock) string {
-	buf := new(strings.Builder)
-
-	buf.WriteString("# ")
-	buf.WriteString(p.ParseDocxBlockText(b.Page))
-	buf.WriteString("\n")
-
-	for _, childId := range b.Children {
-		childBlock := p.blockMap[childId]
-		buf.WriteString(p.ParseDocxBlock(childBlock, 0))
-		buf.WriteString("\n")
+// mergeDocxTextElements 合并样式相同的相邻文本片段，避免输出 `****` 之类的多余标记
+func mergeDocxTextElements(elements []*lark.DocxTextElement) []*lark.DocxTextElement {
+	merged := make([]*lark.DocxTextElement, 0, len(elements))
+	for _, e := range elements {
+		if n := len(merged); n > 0 && e.TextRun != nil && merged[n-1].TextRun != nil &&
+			textElementStyleEqual(merged[n-1].TextRun.TextElementStyle, e.TextRun.TextElementStyle) {
+			last := merged[n-1].TextRun
+			merged[n-1] = &lark.DocxTextElement{
+				TextRun: &lark.DocxTextElementTextRun{
+					Content:          last.Content + e.TextRun.Content,
+					TextElementStyle: last.TextElementStyle,
+				},
+			}
+			continue
+		}
+		merged = append(merged, e)
 	}
-
-	return buf.String()
+	return merged
 }
 
-func (p *Parser) ParseDocxBlockText(b *lark.DocxBlockText) string {
-	buf := new(strings.Builder)
-	numElem := len(b.Elements)
-	for _, e := range b.Elements {
-		inline := numElem > 1
-		buf.WriteString(p.ParseDocxTextElement(e, inline))
+func textElementStyleEqual(a, b *lark.DocxTextElementStyle) bool {
+	if a == nil {
+		a = &lark.DocxTextElementStyle{}
 	}
-	buf.WriteString("\n")
-	return buf.String()
+	if b == nil {
+		b = &lark.DocxTextElementStyle{}
+	}
+	linkA, linkB := "", ""
+	if a.Link != nil {
+		linkA = a.Link.URL
+	}
+	if b.Link != nil {
+		linkB = b.Link.URL
+	}
+	return a.Bold == b.Bold && a.Italic == b.Italic &&
+		a.Strikethrough == b.Strikethrough && a.Underline == b.Underline &&
+		a.InlineCode == b.InlineCode && a.BackgroundColor == b.BackgroundColor &&
+		a.TextColor == b.TextColor && (a.Link != nil) == (b.Link != nil) && linkA == linkB
 }
 
-func (p *Parser) ParseDocxBlockCallout(b *lark.DocxBlock) string {
-	buf := new(strings.Builder)
-
-	buf.WriteString(">[!TIP] \n")
-
-	for _, childId := range b.Children {
-		childBlock := p.blockMap[childId]
-		buf.WriteString(p.ParseDocxBlock(childBlock, 0))
+// buildSheet 将内嵌电子表格构建为包含表格的内嵌节点，token 格式为 <spreadsheetToken>_<sheetId>。
+// 无法拉取数据或超出行列上限时只输出指向原电子表格的链接，拉取失败时记录到 Diagnostics
+func (p *Parser) buildSheet(b *lark.DocxBlock) []*Block {
+	spreadsheetToken, sheetID := splitEmbedToken(b.Sheet.Token)
+	node := &Block{
+		Type:  NodeEmbed,
+		ID:    b.BlockID,
+		Attrs: p.embedAttrs("sheet", b.Sheet.Token, fmt.Sprintf("/sheets/%s?sheet=%s", spreadsheetToken, sheetID)),
 	}
-
-	return buf.String()
-}
-func (p *Parser) ParseDocxTextElement(e *lark.DocxTextElement, inline bool) string {
-	buf := new(strings.Builder)
-	if e.TextRun != nil {
-		buf.WriteString(p.ParseDocxTextElementTextRun(e.TextRun))
+	if p.fetcher == nil || sheetID == "" {
+		return []*Block{node}
 	}
-	if e.MentionUser != nil {
-		buf.WriteString(e.MentionUser.UserID)
+
+	// 多取一行一列用于判断是否超出上限
+	data, err := p.fetcher.GetSheetData(p.ctx, spreadsheetToken, sheetID, p.sheetMaxRows+1, p.sheetMaxCols+1)
+	if err != nil {
+		p.reportFailed(b, fmt.Sprintf("sheet fetch failed: %v", err))
+		return []*Block{node}
 	}
-	if e.MentionDoc != nil {
-		buf.WriteString(
-			fmt.Sprintf("[%s](%s)", e.MentionDoc.Title, utils.UnescapeURL(e.MentionDoc.URL)))
+	values := trimSheetValues(data.Values)
+	if len(values) == 0 || len(values) > p.sheetMaxRows || len(values[0]) > p.sheetMaxCols {
+		return []*Block{node}
 	}
-	if e.Equation != nil {
-		symbol := "$$"
-		if inline {
-			symbol = "$"
+
+	mergeInfoMap := map[int64]map[int64]*lark.DocxBlockTablePropertyMergeInfo{}
+	for _, m := range data.Merges {
+		if m.StartRow >= int64(len(values)) || m.StartColumn >= int64(len(values[0])) {
+			continue
+		}
+		rowSpan := min(m.EndRow, int64(len(values))-1) - m.StartRow + 1
+		colSpan := min(m.EndColumn, int64(len(values[0]))-1) - m.StartColumn + 1
+		if rowSpan <= 1 && colSpan <= 1 {
+			continue
+		}
+		if _, exists := mergeInfoMap[m.StartRow]; !exists {
+			mergeInfoMap[m.StartRow] = map[int64]*lark.DocxBlockTablePropertyMergeInfo{}
+		}
+		mergeInfoMap[m.StartRow][m.StartColumn] = &lark.DocxBlockTablePropertyMergeInfo{
+			RowSpan: rowSpan,
+			ColSpan: colSpan,
 		}
-		buf.WriteString(symbol + strings.TrimSuffix(e.Equation.Content, "\n") + symbol)
 	}
-	return buf.String()
-}
 
-func (p *Parser) ParseDocxTextElementTextRun(tr *lark.DocxTextElementTextRun) string {
-	buf := new(strings.Builder)
-	postWrite := ""
-	if style := tr.TextElementStyle; style != nil {
-		if style.Bold {
-			if p.useHTMLTags {
-				buf.WriteString("<strong>")
-				postWrite = "</strong>"
-			} else {
-				buf.WriteString("**")
-				postWrite = "**"
-			}
-		} else if style.Italic {
-			if p.useHTMLTags {
-				buf.WriteString("<em>")
-				postWrite = "</em>"
-			} else {
-				buf.WriteString("_")
-				postWrite = "_"
-			}
-		} else if style.Strikethrough {
-			if p.useHTMLTags {
-				buf.WriteString("<del>")
-				postWrite = "</del>"
-			} else {
-				buf.WriteString("~~")
-				postWrite = "~~"
-			}
-		} else if style.Underline {
-			buf.WriteString("<u>")
-			postWrite = "</u>"
-		} else if style.InlineCode {
-			buf.WriteString("`")
-			postWrite = "`"
-		} else if link := style.Link; link != nil {
-			buf.WriteString("[")
-			postWrite = fmt.Sprintf("](%s)", utils.UnescapeURL(link.URL))
+	rows := make([][][]*Inline, len(values))
+	for r, row := range values {
+		rows[r] = make([][]*Inline, len(row))
+		for c, cell := range row {
+			rows[r][c] = sheetCellInlines(cell)
 		}
 	}
-	buf.WriteString(tr.Content)
-	buf.WriteString(postWrite)
-	return buf.String()
+	node.Children = []*Block{embedTable(rows, mergeInfoMap)}
+	return []*Block{node}
 }
 
-func (p *Parser) ParseDocxBlockHeading(b *lark.DocxBlock, headingLevel int) string {
-	buf := new(strings.Builder)
-
-	buf.WriteString(strings.Repeat("#", headingLevel))
-	buf.WriteString(" ")
-
-	headingText := reflect.ValueOf(b).Elem().FieldByName(fmt.Sprintf("Heading%d", headingLevel))
-	buf.WriteString(p.ParseDocxBlockText(headingText.Interface().(*lark.DocxBlockText)))
-
-	for _, childId := range b.Children {
-		childBlock := p.blockMap[childId]
-		buf.WriteString(p.ParseDocxBlock(childBlock, 0))
+// buildBitable 将内嵌多维表格的记录构建为包含表格的内嵌节点，或按配置导出为同目录下的 CSV 文件。
+// token 格式为 <appToken>_<tableId>，无法拉取数据时只输出指向原多维表格的链接，拉取失败时记录到 Diagnostics
+func (p *Parser) buildBitable(b *lark.DocxBlock) []*Block {
+	appToken, tableID := splitEmbedToken(b.Bitable.Token)
+	node := &Block{
+		Type:  NodeEmbed,
+		ID:    b.BlockID,
+		Attrs: p.embedAttrs("bitable", b.Bitable.Token, fmt.Sprintf("/base/%s?table=%s", appToken, tableID)),
+	}
+	if p.fetcher == nil || tableID == "" {
+		return []*Block{node}
 	}
 
-	return buf.String()
-}
+	data, err := p.fetcher.GetBitableData(p.ctx, appToken, tableID)
+	if err != nil {
+		p.reportFailed(b, fmt.Sprintf("bitable fetch failed: %v", err))
+		return []*Block{node}
+	}
+	if len(data.Fields) == 0 {
+		return []*Block{node}
+	}
 
-func (p *Parser) ParseDocxBlockImage(img *lark.DocxBlockImage) string {
-	buf := new(strings.Builder)
-	buf.WriteString(fmt.Sprintf("![](%s)", img.Token))
-	buf.WriteString("\n")
-	p.ImgTokens = append(p.ImgTokens, img.Token)
-	return buf.String()
-}
+	rows := make([][][]*Inline, 0, len(data.Records)+1)
+	header := make([][]*Inline, len(data.Fields))
+	for i, field := range data.Fields {
+		header[i] = textInlines(field.Name)
+	}
+	rows = append(rows, header)
+	for _, record := range data.Records {
+		row := make([][]*Inline, len(data.Fields))
+		for i, field := range data.Fields {
+			row[i] = bitableCellInlines(field.Type, record[field.Name])
+		}
+		rows = append(rows, row)
+	}
 
-func (p *Parser) ParseDocxWhatever(body *lark.DocBody) string {
-	buf := new(strings.Builder)
+	if p.bitableMode == BitableModeCSV {
+		records := make([][]string, len(rows))
+		for r, row := range rows {
+			records[r] = make([]string, len(row))
+			for c, inlines := range row {
+				records[r][c] = csvText(inlines)
+			}
+		}
+		buf := new(bytes.Buffer)
+		writer := csv.NewWriter(buf)
+		writer.WriteAll(records)
+		name := b.Bitable.Token + ".csv"
+		p.Sidecars = append(p.Sidecars, &SidecarFile{Name: name, Content: buf.Bytes()})
+		node.Attrs["file"] = name
+		return []*Block{node}
+	}
 
-	return buf.String()
+	node.Children = []*Block{embedTable(rows, nil)}
+	return []*Block{node}
 }
 
-func (p *Parser) ParseDocxBlockBullet(b *lark.DocxBlock, indentLevel int) string {
-	buf := new(strings.Builder)
-
-	buf.WriteString("- ")
-	buf.WriteString(p.ParseDocxBlockText(b.Bullet))
+// 多维表格中需要特殊处理的字段类型
+const (
+	bitableFieldTypeText         = 1
+	bitableFieldTypeDate         = 5
+	bitableFieldTypeCheckbox     = 7
+	bitableFieldTypeLink         = 18
+	bitableFieldTypeDuplexLink   = 21
+	bitableFieldTypeCreatedTime  = 1001
+	bitableFieldTypeModifiedTime = 1002
+)
 
-	for _, childId := range b.Children {
-		childBlock := p.blockMap[childId]
-		buf.WriteString(p.ParseDocxBlock(childBlock, indentLevel+1))
+// bitableCellInlines 将多维表格记录中的字段值转换为行内节点，超链接保留为链接
+func bitableCellInlines(fieldType int64, v interface{}) []*Inline {
+	switch fieldType {
+	case bitableFieldTypeDate, bitableFieldTypeCreatedTime, bitableFieldTypeModifiedTime:
+		if ms, ok := v.(float64); ok {
+			return textInlines(time.UnixMilli(int64(ms)).Format("2006-01-02 15:04"))
+		}
+	case bitableFieldTypeCheckbox:
+		if checked, ok := v.(bool); ok && checked {
+			return textInlines("[x]")
+		}
+		return textInlines("[ ]")
+	case bitableFieldTypeText:
+		// 多行文本由文字、@人、链接等片段组成，直接拼接
+		return bitableValueInlines(v, "")
 	}
-
-	return buf.String()
+	return bitableValueInlines(v, ", ")
 }
 
-func (p *Parser) ParseDocxBlockOrdered(b *lark.DocxBlock, indentLevel int) string {
-	buf := new(strings.Builder)
-
-	// calculate order and indent level
-	parent := p.blockMap[b.ParentID]
-	order := 1
-	for idx, child := range parent.Children {
-		if child == b.BlockID {
-			for i := idx - 1; i >= 0; i-- {
-				if p.blockMap[parent.Children[i]].BlockType == lark.DocxBlockTypeOrdered {
-					order += 1
-				} else {
-					break
-				}
+// bitableValueInlines 将字段原始值转换为行内节点，数组元素之间以 sep 分隔
+func bitableValueInlines(v interface{}, sep string) []*Inline {
+	switch val := v.(type) {
+	case nil:
+		return nil
+	case string:
+		return textInlines(val)
+	case float64:
+		return textInlines(strconv.FormatFloat(val, 'f', -1, 64))
+	case bool:
+		return textInlines(strconv.FormatBool(val))
+	case []interface{}:
+		var inlines []*Inline
+		for i, item := range val {
+			if i > 0 && sep != "" {
+				inlines = append(inlines, &Inline{Type: NodeText, Text: sep})
+			}
+			inlines = append(inlines, bitableValueInlines(item, sep)...)
+		}
+		return inlines
+	case map[string]interface{}:
+		text, _ := val["text"].(string)
+		if link, ok := val["link"].(string); ok && link != "" {
+			if text == "" {
+				text = link
 			}
-			break
+			return []*Inline{{Type: NodeText, Text: text, Attrs: map[string]string{"href": link}}}
+		}
+		if text != "" {
+			return textInlines(text)
+		}
+		// 人员、附件、群组等
+		if name, ok := val["name"].(string); ok {
+			return textInlines(name)
+		}
+		// 地理位置
+		if address, ok := val["full_address"].(string); ok {
+			return textInlines(address)
+		}
+		// 单向关联、双向关联：新版格式以 text_arr 给出被关联记录的文本，
+		// 旧版格式只有记录 ID，由 Client.GetBitableData 替换为记录的文本，无法替换时输出 ID
+		if texts, ok := val["text_arr"]; ok {
+			return bitableValueInlines(texts, ", ")
+		}
+		if ids, ok := val["link_record_ids"]; ok {
+			return bitableValueInlines(ids, ", ")
 		}
+		if value, ok := val["value"]; ok {
+			return bitableValueInlines(value, sep)
+		}
+		return nil
+	default:
+		return textInlines(fmt.Sprint(val))
 	}
+}
 
-	buf.WriteString(fmt.Sprintf("%d. ", order))
-	buf.WriteString(p.ParseDocxBlockText(b.Ordered))
-
-	for _, childId := range b.Children {
-		childBlock := p.blockMap[childId]
-		buf.WriteString(p.ParseDocxBlock(childBlock, indentLevel+1))
+// sheetCellInlines 将电子表格单元格转换为行内节点，超链接片段构建为链接
+func sheetCellInlines(cell *SheetCell) []*Inline {
+	if cell == nil {
+		return nil
 	}
+	var inlines []*Inline
+	for _, seg := range cell.Segments {
+		if seg.Link != "" {
+			inlines = append(inlines, &Inline{Type: NodeText, Text: seg.Text, Attrs: map[string]string{"href": seg.Link}})
+			continue
+		}
+		inlines = append(inlines, textInlines(seg.Text)...)
+	}
+	return inlines
+}
 
-	return buf.String()
+// textInlines 将纯文本转换为行内节点，空文本返回 nil
+func textInlines(text string) []*Inline {
+	if text == "" {
+		return nil
+	}
+	return []*Inline{{Type: NodeText, Text: text}}
 }
 
-func (p *Parser) ParseDocxBlockTableCell(b *lark.DocxBlock) string {
+// csvText 将行内节点转换为 CSV 中的文本，链接保留为 [文本](链接)
+func csvText(inlines []*Inline) string {
 	buf := new(strings.Builder)
-
-	for _, child := range b.Children {
-		block := p.blockMap[child]
-		content := p.ParseDocxBlock(block, 0)
-		buf.WriteString(content + "<br/>")
+	for _, i := range inlines {
+		if href := i.Attrs["href"]; href != "" {
+			buf.WriteString(fmt.Sprintf("[%s](%s)", i.Text, href))
+			continue
+		}
+		buf.WriteString(i.Text)
 	}
-
 	return buf.String()
 }
 
-func (p *Parser) ParseDocxBlockTable(t *lark.DocxBlockTable) string {
-	var rows [][]string
-	mergeInfoMap := map[int64]map[int64]*lark.DocxBlockTablePropertyMergeInfo{}
-
-	// 构建单元格合并信息的映射
-	if t.Property.MergeInfo != nil {
-		for i, merge := range t.Property.MergeInfo {
-			rowIndex := int64(i) / t.Property.ColumnSize
-			colIndex := int64(i) % t.Property.ColumnSize
-			if _, exists := mergeInfoMap[int64(rowIndex)]; !exists {
-				mergeInfoMap[int64(rowIndex)] = map[int64]*lark.DocxBlockTablePropertyMergeInfo{}
+// trimSheetValues 去除末尾的空行和空列，并将各行补齐为相同列数
+func trimSheetValues(values [][]*SheetCell) [][]*SheetCell {
+	numRows, numCols := 0, 0
+	for r, row := range values {
+		for c, cell := range row {
+			if strings.TrimSpace(cell.Text()) != "" {
+				numRows = max(numRows, r+1)
+				numCols = max(numCols, c+1)
 			}
-			mergeInfoMap[rowIndex][colIndex] = merge
 		}
 	}
+	rows := make([][]*SheetCell, numRows)
+	for r := range rows {
+		rows[r] = make([]*SheetCell, numCols)
+		copy(rows[r], values[r])
+	}
+	return rows
+}
 
-	// 构建表格内容
-
-	for i, blockId := range t.Cells {
-		block := p.blockMap[blockId]
-		cellContent := p.ParseDocxBlock(block, 0)
-		cellContent = strings.ReplaceAll(cellContent, "\n", "")
-		rowIndex := int64(i) / t.Property.ColumnSize
-		colIndex := int64(i) % t.Property.ColumnSize
-
-		// 初始化行
-		for len(rows) <= int(rowIndex) {
-			rows = append(rows, []string{})
+// listNumber 返回有序列表项的编号，同一父 Block 下的编号在第一次查询时一并计算
+func (p *Parser) listNumber(b *lark.DocxBlock) int {
+	if number, ok := p.listNumbers[b.BlockID]; ok {
+		return number
+	}
+	if p.listNumbers == nil {
+		p.listNumbers = map[string]int{}
+	}
+	p.listNumbers[b.BlockID] = 1
+	parent := p.blockMap[b.ParentID]
+	if parent == nil {
+		return 1
+	}
+	// previous 为同一层级上一个有序列表项的编号，adjacent 表示它紧挨着当前 Block
+	previous, adjacent := 0, false
+	for _, id := range parent.Children {
+		child := p.blockMap[id]
+		if child == nil || child.BlockType != lark.DocxBlockTypeOrdered {
+			adjacent = false
+			continue
 		}
-		for len(rows[rowIndex]) <= int(colIndex) {
-			rows[rowIndex] = append(rows[rowIndex], "")
+		number := 1
+		sequence := ""
+		if property := p.properties[id]; property != nil {
+			sequence = property.Sequence
 		}
-		// 设置单元格内容
-		rows[rowIndex][colIndex] = cellContent
-	}
-
-	// 渲染为 HTML 表格
-	buf := new(strings.Builder)
-	buf.WriteString("<table>\n")
-
-	// 跟踪已经处理过的合并单元格
-	processedCells := map[string]bool{}
-
-	// 构建 HTML 表格内容
-	for rowIndex, row := range rows {
-		buf.WriteString("<tr>\n")
-		for colIndex, cellContent := range row {
-			cellKey := fmt.Sprintf("%d-%d", rowIndex, colIndex)
-
-			// 跳过已处理的单元格
-			if processedCells[cellKey] {
-				continue
-			}
-
-			mergeInfo := mergeInfoMap[int64(rowIndex)][int64(colIndex)]
-			if mergeInfo != nil {
-
-				// 合并单元格，只有当 RowSpan > 1 或 ColSpan > 1 时才添加对应属性
-				attributes := ""
-				if mergeInfo.RowSpan > 1 {
-					attributes += fmt.Sprintf(` rowspan="%d"`, mergeInfo.RowSpan)
-				}
-				if mergeInfo.ColSpan > 1 {
-					attributes += fmt.Sprintf(` colspan="%d"`, mergeInfo.ColSpan)
-				}
-				buf.WriteString(fmt.Sprintf(
-					`<td%s>%s</td>`,
-					attributes, cellContent,
-				))
-				// 标记合并范围内的所有单元格为已处理
-				for r := rowIndex; r < rowIndex+int(mergeInfo.RowSpan); r++ {
-					for c := colIndex; c < colIndex+int(mergeInfo.ColSpan); c++ {
-						processedCells[fmt.Sprintf("%d-%d", r, c)] = true
-					}
-				}
-			} else {
-				// 普通单元格
-				buf.WriteString(fmt.Sprintf("<td>%s</td>", cellContent))
+		switch {
+		case sequence == "auto":
+			number = previous + 1
+		case sequence != "":
+			if n, err := strconv.Atoi(sequence); err == nil && n > 0 {
+				number = n
 			}
+		case adjacent:
+			// 没有编号属性时，紧挨着的有序列表项视为同一个列表
+			number = previous + 1
 		}
-		buf.WriteString("</tr>\n")
+		p.listNumbers[id] = number
+		previous, adjacent = number, true
 	}
-	buf.WriteString("</table>\n")
-
-	return buf.String()
+	return p.listNumbers[b.BlockID]
 }
 
-func (p *Parser) ParseDocxBlockQuoteContainer(b *lark.DocxBlock) string {
-	buf := new(strings.Builder)
-
-	for _, child := range b.Children {
-		block := p.blockMap[child]
-		buf.WriteString("> ")
-		buf.WriteString(p.ParseDocxBlock(block, 0))
-	}
-
-	return buf.String()
+// imageAligns 图片对齐方式的名称
+var imageAligns = map[lark.DocxAlign]string{
+	lark.DocxAlignLeft:   "left",
+	lark.DocxAlignCenter: "center",
+	lark.DocxAlignRight:  "right",
 }
 
-func (p *Parser) ParseDocxBlockGrid(b *lark.DocxBlock, indentLevel int) string {
-	buf := new(strings.Builder)
-
-	for _, child := range b.Children {
-		columnBlock := p.blockMap[child]
-		for _, child := range columnBlock.Children {
-			block := p.blockMap[child]
-			buf.WriteString(p.ParseDocxBlock(block, indentLevel))
+// imageAttrs 返回图片 Block 的 token、尺寸、对齐方式和图片描述，同一图片在不同 Block 中可以有不同的对齐方式和描述
+func (p *Parser) imageAttrs(b *lark.DocxBlock) map[string]string {
+	img := b.Image
+	attrs := map[string]string{"token": img.Token}
+	if img.Width > 0 && img.Height > 0 {
+		attrs["width"] = strconv.FormatInt(img.Width, 10)
+		attrs["height"] = strconv.FormatInt(img.Height, 10)
+	}
+	if property := p.properties[b.BlockID]; property != nil {
+		if align, ok := imageAligns[property.Align]; ok {
+			attrs["align"] = align
+		}
+		if property.Caption != "" {
+			attrs["caption"] = property.Caption
 		}
 	}
+	return attrs
+}
 
-	return buf.String()
+// tableHeaderRow 表格是否将首行设置为标题行，没有该属性时沿用首行作为表头
+func (p *Parser) tableHeaderRow(blockID string) bool {
+	property, ok := p.properties[blockID]
+	return !ok || property.HeaderRow
 }
@@ -1,11 +1,13 @@
 package core_test
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"path"
+	"strings"
 	"testing"
 
 	"github.com/88250/lute"
@@ -52,3 +54,1141 @@ func TestParseDocxContent(t *testing.T) {
 		})
 	}
 }
+
+// newTestDocx 以第一个 Block 作为页面 Block 构造测试文档
+func newTestDocx(blocks ...*lark.DocxBlock) (*lark.DocxDocument, []*lark.DocxBlock) {
+	return &lark.DocxDocument{DocumentID: blocks[0].BlockID}, blocks
+}
+
+func textBlock(content string) *lark.DocxBlockText {
+	return &lark.DocxBlockText{
+		Elements: []*lark.DocxTextElement{
+			{TextRun: &lark.DocxTextElementTextRun{Content: content}},
+		},
+	}
+}
+
+func TestParseDocxBlockFile(t *testing.T) {
+	doc, blocks := newTestDocx(
+		&lark.DocxBlock{
+			BlockID:   "page",
+			BlockType: lark.DocxBlockTypePage,
+			Page:      textBlock("附件"),
+			Children:  []string{"view"},
+		},
+		&lark.DocxBlock{
+			BlockID:   "view",
+			ParentID:  "page",
+			BlockType: lark.DocxBlockTypeView,
+			View:      &lark.DocxBlockView{ViewType: lark.DocxViewTypeCard},
+			Children:  []string{"file"},
+		},
+		&lark.DocxBlock{
+			BlockID:   "file",
+			ParentID:  "view",
+			BlockType: lark.DocxBlockTypeFile,
+			File:      &lark.DocxBlockFile{Token: "boxcnFileToken", Name: "spec.pdf"},
+		},
+	)
+
+	parser := core.NewParser(core.NewConfig("", "").Output)
+	mdParsed := parser.ParseDocxContent(doc, blocks)
+
+	assert.Contains(t, mdParsed, "[spec.pdf](boxcnFileToken)")
+	assert.Equal(t, []string{"boxcnFileToken"}, parser.FileTokens)
+}
+
+type fakeEmbedFetcher struct {
+	sheets   map[string]*core.SheetData
+	bitables map[string]*core.BitableData
+}
+
+func (f *fakeEmbedFetcher) GetSheetData(ctx context.Context, spreadsheetToken, sheetID string, maxRows, maxCols int) (*core.SheetData, error) {
+	data, ok := f.sheets[spreadsheetToken+"_"+sheetID]
+	if !ok {
+		return nil, fmt.Errorf("sheet %s not found", sheetID)
+	}
+	return data, nil
+}
+
+func (f *fakeEmbedFetcher) GetBitableData(ctx context.Context, appToken, tableID string) (*core.BitableData, error) {
+	data, ok := f.bitables[appToken+"_"+tableID]
+	if !ok {
+		return nil, fmt.Errorf("table %s not found", tableID)
+	}
+	return data, nil
+}
+
+func sheetDocx(token string) (*lark.DocxDocument, []*lark.DocxBlock) {
+	return newTestDocx(
+		&lark.DocxBlock{
+			BlockID:   "page",
+			BlockType: lark.DocxBlockTypePage,
+			Page:      textBlock("电子表格"),
+			Children:  []string{"sheet"},
+		},
+		&lark.DocxBlock{
+			BlockID:   "sheet",
+			ParentID:  "page",
+			BlockType: lark.DocxBlockTypeSheet,
+			Sheet:     &lark.DocxBlockSheet{Token: token},
+		},
+	)
+}
+
+// sheetRow 将纯文本构建为电子表格的一行单元格
+func sheetRow(texts ...string) []*core.SheetCell {
+	row := make([]*core.SheetCell, len(texts))
+	for i, text := range texts {
+		row[i] = &core.SheetCell{Segments: []*core.SheetSegment{{Text: text}}}
+	}
+	return row
+}
+
+func TestParseDocxBlockSheet(t *testing.T) {
+	fetcher := &fakeEmbedFetcher{sheets: map[string]*core.SheetData{
+		"shtcnPlain_abc": {
+			Values: [][]*core.SheetCell{
+				sheetRow("Name", "Value", ""),
+				sheetRow("a|b", "1.5", ""),
+				sheetRow("", "", ""),
+			},
+		},
+		"shtcnMerged_abc": {
+			Values: [][]*core.SheetCell{
+				sheetRow("Group", ""),
+				sheetRow("x", "y"),
+			},
+			Merges: []*core.SheetMerge{{StartRow: 0, EndRow: 0, StartColumn: 0, EndColumn: 1}},
+		},
+		"shtcnLarge_abc": {
+			Values: [][]*core.SheetCell{sheetRow("1"), sheetRow("2"), sheetRow("3")},
+		},
+		"shtcnLink_abc": {
+			Values: [][]*core.SheetCell{
+				sheetRow("Name", "Repo"),
+				{
+					{Segments: []*core.SheetSegment{{Text: "feishu2md"}}},
+					{Segments: []*core.SheetSegment{{Text: "see "}, {Text: "repo", Link: "https://e.com"}}},
+				},
+			},
+		},
+	}}
+	config := core.NewConfig("", "").Output
+	config.SheetMaxRows = 2
+	config.TableMode = core.TableModeAuto
+
+	tests := []struct {
+		name  string
+		token string
+		want  string
+	}{
+		{"markdown table", "shtcnPlain_abc", "| a\\|b |   1.5 |"},
+		{"merged cells as html", "shtcnMerged_abc", `<td colspan="2">Group</td>`},
+		{"fallback link when exceeding cap", "shtcnLarge_abc", "[Sheet](https://sample.feishu.cn/sheets/shtcnLarge?sheet=abc)"},
+		{"fallback link on fetch error", "shtcnMissing_abc", "[Sheet](https://sample.feishu.cn/sheets/shtcnMissing?sheet=abc)"},
+		{"hyperlink cell", "shtcnLink_abc", "| feishu2md | see [repo](https://e.com) |"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := core.NewParser(config)
+			parser.SetEmbedFetcher(context.Background(), fetcher)
+			parser.SetBaseURL("https://sample.feishu.cn")
+			doc, blocks := sheetDocx(tt.token)
+			assert.Contains(t, parser.ParseDocxContent(doc, blocks), tt.want)
+		})
+	}
+
+	t.Run("table mode", func(t *testing.T) {
+		// 与文档中的表格一样按 table_mode 输出
+		tableConfig := config
+		tableConfig.TableMode = core.TableModeGFM
+		parser := core.NewParser(tableConfig)
+		parser.SetEmbedFetcher(context.Background(), fetcher)
+		doc, blocks := sheetDocx("shtcnMerged_abc")
+		assert.Contains(t, parser.ParseDocxContent(doc, blocks), "| Group |   |")
+
+		tableConfig.TableMode = core.TableModeHTML
+		parser = core.NewParser(tableConfig)
+		parser.SetEmbedFetcher(context.Background(), fetcher)
+		doc, blocks = sheetDocx("shtcnPlain_abc")
+		assert.Contains(t, parser.ParseDocxContent(doc, blocks), "<td>a|b</td>")
+	})
+
+	t.Run("diagnostics", func(t *testing.T) {
+		// 拉取失败时记录诊断信息，超出行列上限时只输出链接
+		parser := core.NewParser(config)
+		parser.SetEmbedFetcher(context.Background(), fetcher)
+		doc, blocks := sheetDocx("shtcnMissing_abc")
+		parser.ParseDocxContent(doc, blocks)
+		assert.Len(t, parser.Diagnostics, 1)
+		assert.Equal(t, "block sheet (30) sheet: sheet fetch failed: sheet abc not found", parser.Diagnostics[0].String())
+
+		parser = core.NewParser(config)
+		parser.SetEmbedFetcher(context.Background(), fetcher)
+		doc, blocks = sheetDocx("shtcnLarge_abc")
+		parser.ParseDocxContent(doc, blocks)
+		assert.Empty(t, parser.Diagnostics)
+	})
+
+	t.Run("html", func(t *testing.T) {
+		parser := core.NewParser(config)
+		parser.SetEmbedFetcher(context.Background(), fetcher)
+		doc, blocks := sheetDocx("shtcnMerged_abc")
+		assert.Contains(t, core.NewHTMLEmitter(config).Emit(parser.BuildDocument(doc, blocks)),
+			"<tr>\n<th colspan=\"2\">Group</th>\n</tr>\n<tr>\n<td>x</td>\n<td>y</td>\n</tr>\n")
+
+		doc, blocks = sheetDocx("shtcnLink_abc")
+		assert.Contains(t, core.NewHTMLEmitter(config).Emit(parser.BuildDocument(doc, blocks)),
+			`<td>see <a href="https://e.com">repo</a></td>`)
+	})
+}
+
+func TestParseDocxBlockBitable(t *testing.T) {
+	fetcher := &fakeEmbedFetcher{bitables: map[string]*core.BitableData{
+		"bascnApp_tblTasks": {
+			Fields: []*core.BitableField{
+				{Name: "Task", Type: 1},
+				{Name: "Tags", Type: 4},
+				{Name: "Owner", Type: 11},
+				{Name: "Link", Type: 15},
+				{Name: "Done", Type: 7},
+				{Name: "Related", Type: 18},
+				{Name: "Blocked", Type: 21},
+			},
+			Records: []map[string]interface{}{
+				{
+					"Task":    []interface{}{map[string]interface{}{"type": "text", "text": "Write spec"}},
+					"Tags":    []interface{}{"doc", "p0"},
+					"Owner":   []interface{}{map[string]interface{}{"id": "ou_1", "name": "Alice"}, map[string]interface{}{"id": "ou_2", "name": "Bob"}},
+					"Link":    map[string]interface{}{"text": "repo", "link": "https://example.com"},
+					"Done":    true,
+					"Related": map[string]interface{}{"text_arr": []interface{}{"Design", "Review"}, "record_ids": []interface{}{"rec1", "rec2"}},
+					"Blocked": map[string]interface{}{"link_record_ids": []interface{}{"recMissing"}},
+				},
+			},
+		},
+	}}
+	doc, blocks := newTestDocx(
+		&lark.DocxBlock{
+			BlockID:   "page",
+			BlockType: lark.DocxBlockTypePage,
+			Page:      textBlock("多维表格"),
+			Children:  []string{"bitable"},
+		},
+		&lark.DocxBlock{
+			BlockID:   "bitable",
+			ParentID:  "page",
+			BlockType: lark.DocxBlockTypeBitable,
+			Bitable:   &lark.DocxBlockBitable{Token: "bascnApp_tblTasks"},
+		},
+	)
+
+	t.Run("inline", func(t *testing.T) {
+		config := core.NewConfig("", "").Output
+		config.TableMode = core.TableModeAuto
+		parser := core.NewParser(config)
+		parser.SetEmbedFetcher(context.Background(), fetcher)
+		mdParsed := parser.ParseDocxContent(doc, blocks)
+		assert.Contains(t, mdParsed, "| Write spec | doc, p0 | Alice, Bob | [repo](https://example.com) | \\[x\\] | Design, Review | recMissing |")
+		assert.Empty(t, parser.Sidecars)
+
+		// 与文档中的表格一样按 table_mode 输出
+		parser = core.NewParser(core.NewConfig("", "").Output)
+		parser.SetEmbedFetcher(context.Background(), fetcher)
+		assert.Contains(t, parser.ParseDocxContent(doc, blocks), "<td>Write spec</td>")
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		config := core.NewConfig("", "").Output
+		config.BitableMode = core.BitableModeCSV
+		parser := core.NewParser(config)
+		parser.SetEmbedFetcher(context.Background(), fetcher)
+		mdParsed := parser.ParseDocxContent(doc, blocks)
+		assert.Contains(t, mdParsed, "[Bitable](bascnApp_tblTasks.csv)")
+		assert.Len(t, parser.Sidecars, 1)
+		assert.Equal(t, "bascnApp_tblTasks.csv", parser.Sidecars[0].Name)
+		assert.Equal(t,
+			"Task,Tags,Owner,Link,Done,Related,Blocked\nWrite spec,\"doc, p0\",\"Alice, Bob\",[repo](https://example.com),[x],\"Design, Review\",recMissing\n",
+			string(parser.Sidecars[0].Content))
+	})
+
+	t.Run("fetch error", func(t *testing.T) {
+		parser := core.NewParser(core.NewConfig("", "").Output)
+		parser.SetEmbedFetcher(context.Background(), &fakeEmbedFetcher{})
+		assert.Contains(t, parser.ParseDocxContent(doc, blocks), "Bitable: `bascnApp_tblTasks`\n")
+		assert.Len(t, parser.Diagnostics, 1)
+		assert.Equal(t, "block bitable (18) bitable: bitable fetch failed: table tblTasks not found", parser.Diagnostics[0].String())
+	})
+}
+
+func TestParseDocxBlockBoard(t *testing.T) {
+	doc, blocks := newTestDocx(
+		&lark.DocxBlock{
+			BlockID:   "page",
+			BlockType: lark.DocxBlockTypePage,
+			Page:      textBlock("架构图"),
+			Children:  []string{"board", "diagram"},
+		},
+		&lark.DocxBlock{
+			BlockID:   "board",
+			ParentID:  "page",
+			BlockType: core.DocxBlockTypeBoard,
+		},
+		&lark.DocxBlock{
+			BlockID:   "diagram",
+			ParentID:  "page",
+			BlockType: lark.DocxBlockTypeDiagram,
+			Diagram:   &lark.DocxBlockDiagram{DiagramType: lark.DocxDiagramTypeUML},
+		},
+	)
+
+	parser := core.NewParser(core.NewConfig("", "").Output)
+	mdParsed := parser.ParseDocxContent(doc, blocks)
+
+	assert.Contains(t, mdParsed, "![](board)")
+	assert.Contains(t, mdParsed, "![](diagram)")
+	assert.Equal(t, []string{"board", "diagram"}, parser.BoardBlocks)
+
+	t.Run("export failed", func(t *testing.T) {
+		// 旧版流程图无法导出为图片，输出指向原文档的链接并记录诊断信息
+		parser := core.NewParser(core.NewConfig("", "").Output)
+		parser.SetBaseURL("https://example.feishu.cn")
+		parser.SetAssetResolver(func(asset *core.Asset) string {
+			if asset.Token == "diagram" {
+				return ""
+			}
+			return "static/" + asset.Token + ".png"
+		})
+		document := parser.BuildDocument(doc, blocks)
+		assert.Equal(t, "# 架构图\n\n"+
+			"![](static/board.png)\n\n"+
+			"[Board](https://example.feishu.cn/docx/page#diagram)\n",
+			core.NewMarkdownEmitter(core.NewConfig("", "").Output).Emit(document))
+		assert.Contains(t, core.NewHTMLEmitter(core.NewConfig("", "").Output).Emit(document),
+			`<p><a href="https://example.feishu.cn/docx/page#diagram">Board</a></p>`)
+		assert.Len(t, parser.Diagnostics, 1)
+		assert.Equal(t, "block diagram (21) diagram: board export failed", parser.Diagnostics[0].String())
+
+		// 未设置站点地址时输出 Block ID
+		parser = core.NewParser(core.NewConfig("", "").Output)
+		parser.SetAssetResolver(func(asset *core.Asset) string { return "" })
+		assert.Contains(t, parser.ParseDocxContent(doc, blocks), "Board: `diagram`\n")
+	})
+}
+
+func TestBlockRenderer(t *testing.T) {
+	doc, blocks := newTestDocx(
+		&lark.DocxBlock{
+			BlockID:   "page",
+			BlockType: lark.DocxBlockTypePage,
+			Page:      textBlock("渲染器"),
+			Children:  []string{"isv", "divider", "mindnote"},
+		},
+		&lark.DocxBlock{
+			BlockID:   "isv",
+			ParentID:  "page",
+			BlockType: lark.DocxBlockTypeISV,
+			ISV:       &lark.DocxBlockISV{ComponentTypeID: "blk_poll"},
+		},
+		&lark.DocxBlock{
+			BlockID:   "divider",
+			ParentID:  "page",
+			BlockType: lark.DocxBlockTypeDivider,
+		},
+		&lark.DocxBlock{
+			BlockID:   "mindnote",
+			ParentID:  "page",
+			BlockType: lark.DocxBlockTypeMindnote,
+		},
+	)
+
+	config := core.NewConfig("", "").Output
+	parser := core.NewParser(config)
+	parser.SetBlockRenderer(lark.DocxBlockTypeISV, core.BlockRendererFunc(
+		func(p *core.Parser, b *lark.DocxBlock) []*core.Block {
+			return []*core.Block{{Type: core.NodeHTML, Text: "<!-- isv: " + b.ISV.ComponentTypeID + " -->"}}
+		}))
+	parser.SetBlockRenderer(lark.DocxBlockTypeDivider, core.BlockRendererFunc(
+		func(p *core.Parser, b *lark.DocxBlock) []*core.Block {
+			return []*core.Block{{Type: core.NodeParagraph, Inlines: []*core.Inline{{Type: core.NodeText, Text: "* * *"}}}}
+		}))
+	parser.SetFallbackRenderer(core.BlockRendererFunc(
+		func(p *core.Parser, b *lark.DocxBlock) []*core.Block {
+			return []*core.Block{{Type: core.NodeHTML, Text: fmt.Sprintf("<!-- unsupported block %d -->", b.BlockType)}}
+		}))
+
+	assert.Equal(t, "# 渲染器\n\n"+
+		"<!-- isv: blk_poll -->\n\n"+
+		"\\* \\* \\*\n\n"+
+		"<!-- unsupported block 29 -->\n",
+		parser.ParseDocxContent(doc, blocks))
+
+	// HTML 由同一个 AST 输出，同样使用自定义渲染器
+	assert.Equal(t, "<h1>渲染器</h1>\n"+
+		"<!-- isv: blk_poll -->\n"+
+		"<p>* * *</p>\n"+
+		"<!-- unsupported block 29 -->\n",
+		core.NewHTMLEmitter(config).Emit(parser.BuildDocument(doc, blocks)))
+
+	// 其他 Parser 不受影响
+	other := core.NewParser(config)
+	assert.Equal(t, "# 渲染器\n\n---\n", other.ParseDocxContent(doc, blocks))
+}
+
+func TestParseDocxUnsupportedBlock(t *testing.T) {
+	doc, blocks := newTestDocx(
+		&lark.DocxBlock{
+			BlockID:   "page",
+			BlockType: lark.DocxBlockTypePage,
+			Page:      textBlock("诊断"),
+			Children:  []string{"h1", "h2", "isv", "h2b", "quote"},
+		},
+		&lark.DocxBlock{BlockID: "h1", ParentID: "page", BlockType: lark.DocxBlockTypeHeading1, Heading1: textBlock("概述")},
+		&lark.DocxBlock{BlockID: "h2", ParentID: "page", BlockType: lark.DocxBlockTypeHeading2, Heading2: textBlock("安装")},
+		&lark.DocxBlock{BlockID: "isv", ParentID: "page", BlockType: lark.DocxBlockTypeISV},
+		&lark.DocxBlock{BlockID: "h2b", ParentID: "page", BlockType: lark.DocxBlockTypeHeading2, Heading2: textBlock("使用")},
+		&lark.DocxBlock{
+			BlockID:   "quote",
+			ParentID:  "page",
+			BlockType: lark.DocxBlockTypeQuoteContainer,
+			Children:  []string{"mindnote"},
+		},
+		&lark.DocxBlock{BlockID: "mindnote", ParentID: "quote", BlockType: lark.DocxBlockTypeMindnote},
+	)
+
+	t.Run("diagnostics", func(t *testing.T) {
+		parser := core.NewParser(core.NewConfig("", "").Output)
+		assert.NotContains(t, parser.ParseDocxContent(doc, blocks), "unsupported")
+		assert.Equal(t, []*core.Diagnostic{
+			{BlockType: lark.DocxBlockTypeISV, BlockID: "isv", HeadingPath: []string{"概述", "安装"}},
+			{BlockType: lark.DocxBlockTypeMindnote, BlockID: "mindnote", HeadingPath: []string{"概述", "使用"}},
+		}, parser.Diagnostics)
+		assert.Equal(t, "unsupported block isv (28) isv under 概述 > 安装", parser.Diagnostics[0].String())
+	})
+
+	t.Run("placeholder", func(t *testing.T) {
+		config := core.NewConfig("", "").Output
+		config.UnsupportedPlaceholder = true
+		parser := core.NewParser(config)
+		markdown := parser.ParseDocxContent(doc, blocks)
+		assert.Contains(t, markdown, "<!-- feishu2md: unsupported block isv (28) isv -->\n")
+		assert.Contains(t, markdown, "<!-- feishu2md: unsupported block mindnote (29) mindnote -->")
+	})
+
+	t.Run("ast", func(t *testing.T) {
+		config := core.NewConfig("", "").Output
+		config.UnsupportedPlaceholder = true
+		parser := core.NewParser(config)
+		document := parser.BuildDocument(doc, blocks)
+		assert.Len(t, parser.Diagnostics, 2)
+		assert.Contains(t, core.NewMarkdownEmitter(config).Emit(document),
+			"<!-- feishu2md: unsupported block isv (28) isv -->\n")
+		assert.Contains(t, core.NewHTMLEmitter(config).Emit(document),
+			"<!-- feishu2md: unsupported block mindnote (29) mindnote -->\n")
+	})
+
+	t.Run("skip", func(t *testing.T) {
+		parser := core.NewParser(core.NewConfig("", "").Output)
+		parser.SetFallbackRenderer(core.SkipBlockRenderer)
+		parser.ParseDocxContent(doc, blocks)
+		assert.Empty(t, parser.Diagnostics)
+	})
+}
+
+func TestParseDocxObsidianEmbeds(t *testing.T) {
+	doc, blocks := newTestDocx(
+		&lark.DocxBlock{
+			BlockID:   "page",
+			BlockType: lark.DocxBlockTypePage,
+			Page:      textBlock("图片"),
+			Children:  []string{"image", "board"},
+		},
+		&lark.DocxBlock{
+			BlockID:   "image",
+			ParentID:  "page",
+			BlockType: lark.DocxBlockTypeImage,
+			Image:     &lark.DocxBlockImage{Token: "boxcnImage"},
+		},
+		&lark.DocxBlock{
+			BlockID:   "board",
+			ParentID:  "page",
+			BlockType: core.DocxBlockTypeBoard,
+		},
+	)
+
+	config := core.NewConfig("", "").Output
+	config.Flavor = core.FlavorObsidian
+	parser := core.NewParser(config)
+	mdParsed := parser.ParseDocxContent(doc, blocks)
+
+	assert.Contains(t, mdParsed, "![[boxcnImage]]\n")
+	assert.Contains(t, mdParsed, "![[board]]\n")
+	assert.Equal(t, []string{"boxcnImage"}, parser.ImgTokens)
+}
+
+func TestParseDocxTextStyle(t *testing.T) {
+	root := utils.RootDir()
+	engine := lute.New(func(l *lute.Lute) {
+		l.RenderOptions.AutoSpace = true
+	})
+
+	jsonFile, err := os.ReadFile(path.Join(root, "testdata", "textstyle.json"))
+	utils.CheckErr(err)
+	data := struct {
+		Document *lark.DocxDocument `json:"document"`
+		Blocks   []*lark.DocxBlock  `json:"blocks"`
+	}{}
+	utils.CheckErr(json.Unmarshal(jsonFile, &data))
+
+	tests := []struct {
+		golden      string
+		useHTMLTags bool
+	}{
+		{"textstyle.md", false},
+		{"textstyle.html.md", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			config := core.NewConfig("", "").Output
+			config.UseHTMLTags = tt.useHTMLTags
+			parser := core.NewParser(config)
+			mdParsed := engine.FormatStr("md", parser.ParseDocxContent(data.Document, data.Blocks))
+
+			mdFile, err := os.ReadFile(path.Join(root, "testdata", tt.golden))
+			utils.CheckErr(err)
+			assert.Equal(t, string(mdFile), mdParsed)
+		})
+	}
+}
+
+func TestParseDocxTextColor(t *testing.T) {
+	doc, blocks := newTestDocx(
+		&lark.DocxBlock{
+			BlockID:   "page",
+			BlockType: lark.DocxBlockTypePage,
+			Page:      textBlock("颜色"),
+			Children:  []string{"text"},
+		},
+		&lark.DocxBlock{
+			BlockID:   "text",
+			ParentID:  "page",
+			BlockType: lark.DocxBlockTypeText,
+			Text: &lark.DocxBlockText{
+				Elements: []*lark.DocxTextElement{
+					{TextRun: &lark.DocxTextElementTextRun{
+						Content:          "决定",
+						TextElementStyle: &lark.DocxTextElementStyle{Bold: true, BackgroundColor: 3},
+					}},
+					{TextRun: &lark.DocxTextElementTextRun{
+						Content:          "风险",
+						TextElementStyle: &lark.DocxTextElementStyle{TextColor: 1},
+					}},
+				},
+			},
+		},
+	)
+
+	tests := []struct {
+		colorMode        string
+		backgroundColors map[int]string
+		want             string
+	}{
+		{core.ColorModeNone, nil, "**决定**风险"},
+		{core.ColorModeHTML, nil, `<mark style="background-color:#fff67a">**决定**</mark><span style="color:#d83931">风险</span>`},
+		{core.ColorModeHTML, map[int]string{3: "yellow"}, `<mark style="background-color:yellow">**决定**</mark>`},
+		{core.ColorModeObsidian, nil, `==**决定**==<span style="color:#d83931">风险</span>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.colorMode, func(t *testing.T) {
+			config := core.NewConfig("", "").Output
+			config.ColorMode = tt.colorMode
+			config.BackgroundColors = tt.backgroundColors
+			parser := core.NewParser(config)
+			assert.Contains(t, parser.ParseDocxContent(doc, blocks), tt.want)
+		})
+	}
+}
+
+func TestParseDocxBlockCallout(t *testing.T) {
+	calloutDocx := func(callout *lark.DocxBlockCallout) (*lark.DocxDocument, []*lark.DocxBlock) {
+		return newTestDocx(
+			&lark.DocxBlock{
+				BlockID:   "page",
+				BlockType: lark.DocxBlockTypePage,
+				Page:      textBlock("高亮块"),
+				Children:  []string{"callout"},
+			},
+			&lark.DocxBlock{
+				BlockID:   "callout",
+				ParentID:  "page",
+				BlockType: lark.DocxBlockTypeCallout,
+				Callout:   callout,
+				Children:  []string{"text", "bullet1", "bullet2", "code"},
+			},
+			&lark.DocxBlock{
+				BlockID:   "text",
+				ParentID:  "callout",
+				BlockType: lark.DocxBlockTypeText,
+				Text:      textBlock("升级前请先备份"),
+			},
+			&lark.DocxBlock{
+				BlockID:   "bullet1",
+				ParentID:  "callout",
+				BlockType: lark.DocxBlockTypeBullet,
+				Bullet:    textBlock("数据库"),
+				Children:  []string{"nested"},
+			},
+			&lark.DocxBlock{
+				BlockID:   "nested",
+				ParentID:  "bullet1",
+				BlockType: lark.DocxBlockTypeBullet,
+				Bullet:    textBlock("主库"),
+			},
+			&lark.DocxBlock{
+				BlockID:   "bullet2",
+				ParentID:  "callout",
+				BlockType: lark.DocxBlockTypeBullet,
+				Bullet:    textBlock("配置文件"),
+			},
+			&lark.DocxBlock{
+				BlockID:   "code",
+				ParentID:  "callout",
+				BlockType: lark.DocxBlockTypeCode,
+				Code: &lark.DocxBlockText{
+					Elements: textBlock("make backup\nmake upgrade").Elements,
+					Style:    &lark.DocxTextStyle{Language: 7},
+				},
+			},
+		)
+	}
+
+	t.Run("quote every line", func(t *testing.T) {
+		doc, blocks := calloutDocx(&lark.DocxBlockCallout{EmojiID: "warning"})
+		parser := core.NewParser(core.NewConfig("", "").Output)
+		assert.Contains(t, parser.ParseDocxContent(doc, blocks), "> [!WARNING] ⚠️\n"+
+			"> 升级前请先备份\n"+
+			">\n"+
+			"> - 数据库\n"+
+			"> \t- 主库\n"+
+			"> - 配置文件\n"+
+			">\n"+
+			"> ```bash\n"+
+			"> make backup\n"+
+			"> make upgrade\n"+
+			"> ```\n")
+	})
+
+	tests := []struct {
+		name    string
+		callout *lark.DocxBlockCallout
+		config  func(*core.OutputConfig)
+		want    string
+	}{
+		{"match by background color", &lark.DocxBlockCallout{BackgroundColor: 5}, nil, "> [!NOTE]\n"},
+		{"emoji takes precedence", &lark.DocxBlockCallout{EmojiID: "bulb", BackgroundColor: 1}, nil, "> [!TIP] 💡\n"},
+		{"unknown emoji as shortcode", &lark.DocxBlockCallout{EmojiID: "unicorn"}, nil, "> [!TIP] :unicorn:\n"},
+		{"configured mapping", &lark.DocxBlockCallout{EmojiID: "bulb"}, func(c *core.OutputConfig) {
+			c.CalloutEmojiTypes = map[string]string{"bulb": "important"}
+		}, "> [!IMPORTANT] 💡\n"},
+		{"obsidian flavor", &lark.DocxBlockCallout{EmojiID: "warning"}, func(c *core.OutputConfig) {
+			c.Flavor = core.FlavorObsidian
+		}, "> [!warning] ⚠️\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := core.NewConfig("", "").Output
+			if tt.config != nil {
+				tt.config(&config)
+			}
+			doc, blocks := calloutDocx(tt.callout)
+			parser := core.NewParser(config)
+			assert.Contains(t, parser.ParseDocxContent(doc, blocks), tt.want)
+		})
+	}
+}
+
+func TestParseDocxBlockTable(t *testing.T) {
+	tableDocx := func(mergeInfo []*lark.DocxBlockTablePropertyMergeInfo) (*lark.DocxDocument, []*lark.DocxBlock) {
+		blocks := []*lark.DocxBlock{
+			{
+				BlockID:   "page",
+				BlockType: lark.DocxBlockTypePage,
+				Page:      textBlock("表格"),
+				Children:  []string{"table"},
+			},
+			{
+				BlockID:   "table",
+				ParentID:  "page",
+				BlockType: lark.DocxBlockTypeTable,
+				Table: &lark.DocxBlockTable{
+					Cells: []string{"c1", "c2", "c3", "c4"},
+					Property: &lark.DocxBlockTableProperty{
+						RowSize:    2,
+						ColumnSize: 2,
+						MergeInfo:  mergeInfo,
+					},
+				},
+			},
+		}
+		for i, content := range []string{"Key", "Value", "a|b", "c"} {
+			cellID := fmt.Sprintf("c%d", i+1)
+			blocks = append(blocks,
+				&lark.DocxBlock{
+					BlockID:   cellID,
+					ParentID:  "table",
+					BlockType: lark.DocxBlockTypeTableCell,
+					Children:  []string{cellID + "_text"},
+				},
+				&lark.DocxBlock{
+					BlockID:   cellID + "_text",
+					ParentID:  cellID,
+					BlockType: lark.DocxBlockTypeText,
+					Text:      textBlock(content),
+				},
+			)
+		}
+		return newTestDocx(blocks...)
+	}
+	merged := []*lark.DocxBlockTablePropertyMergeInfo{
+		{RowSpan: 1, ColSpan: 2}, {RowSpan: 1, ColSpan: 1},
+		{RowSpan: 1, ColSpan: 1}, {RowSpan: 1, ColSpan: 1},
+	}
+
+	tests := []struct {
+		name       string
+		tableMode  string
+		mergeInfo  []*lark.DocxBlockTablePropertyMergeInfo
+		properties core.DocxBlockProperties
+		want       string
+	}{
+		{"empty defaults to html", "", nil, nil, "<td>Key</td><td>Value</td>"},
+		{"auto without merged cells", core.TableModeAuto, nil, nil, "| Key  | Value |\n|------|-------|\n| a\\|b | c     |\n"},
+		{"auto with merged cells", core.TableModeAuto, merged, nil, `<td colspan="2">Key</td>`},
+		{"html", core.TableModeHTML, nil, nil, "<td>Key</td><td>Value</td>"},
+		{"gfm ignores merged cells", core.TableModeGFM, merged, nil, "| Key  |   |\n|------|---|\n| a\\|b | c |\n"},
+		{"gfm keeps columns under row span", core.TableModeGFM, []*lark.DocxBlockTablePropertyMergeInfo{
+			{RowSpan: 2, ColSpan: 1}, {RowSpan: 1, ColSpan: 1},
+			{RowSpan: 1, ColSpan: 1}, {RowSpan: 1, ColSpan: 1},
+		}, nil, "| Key | Value |\n|-----|-------|\n|     | c     |\n"},
+		{"gfm without header row", core.TableModeGFM, nil,
+			core.DocxBlockProperties{"table": {HeaderRow: false}},
+			"|      |       |\n|------|-------|\n| Key  | Value |\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := core.NewConfig("", "").Output
+			config.TableMode = tt.tableMode
+			parser := core.NewParser(config)
+			parser.SetBlockProperties(tt.properties)
+			doc, blocks := tableDocx(tt.mergeInfo)
+			assert.Contains(t, parser.ParseDocxContent(doc, blocks), tt.want)
+		})
+	}
+}
+
+type fakeUserResolver map[string]*core.UserInfo
+
+func (f fakeUserResolver) BatchGetUsers(ctx context.Context, openIDs []string) (map[string]*core.UserInfo, error) {
+	users := map[string]*core.UserInfo{}
+	for _, openID := range openIDs {
+		if user, ok := f[openID]; ok {
+			users[openID] = user
+		}
+	}
+	return users, nil
+}
+
+func TestParseDocxMentionUser(t *testing.T) {
+	doc, blocks := newTestDocx(
+		&lark.DocxBlock{
+			BlockID:   "page",
+			BlockType: lark.DocxBlockTypePage,
+			Page:      textBlock("提及"),
+			Children:  []string{"text"},
+		},
+		&lark.DocxBlock{
+			BlockID:   "text",
+			ParentID:  "page",
+			BlockType: lark.DocxBlockTypeText,
+			Text: &lark.DocxBlockText{
+				Elements: []*lark.DocxTextElement{
+					{MentionUser: &lark.DocxTextElementMentionUser{UserID: "ou_alice"}},
+					{TextRun: &lark.DocxTextElementTextRun{Content: " 和 "}},
+					{MentionUser: &lark.DocxTextElementMentionUser{UserID: "ou_unknown"}},
+				},
+			},
+		},
+	)
+	resolver := fakeUserResolver{"ou_alice": {Name: "Alice", Email: "alice@example.com"}}
+
+	tests := []struct {
+		name     string
+		config   func(*core.OutputConfig)
+		resolver core.UserResolver
+		want     string
+	}{
+		{"without resolver", nil, nil, "ou_alice 和 ou_unknown"},
+		{"name", nil, resolver, "@Alice 和 ou_unknown"},
+		{"mailto", func(c *core.OutputConfig) {
+			c.MentionMode = core.MentionModeMailto
+		}, resolver, "[@Alice](mailto:alice@example.com) 和 ou_unknown"},
+		{"template", func(c *core.OutputConfig) {
+			c.MentionMode = core.MentionModeTemplate
+			c.MentionTemplate = "[{{.Name}}](https://people.example.com/{{.OpenID}})"
+		}, resolver, "[Alice](https://people.example.com/ou_alice) 和 ou_unknown"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := core.NewConfig("", "").Output
+			if tt.config != nil {
+				tt.config(&config)
+			}
+			parser := core.NewParser(config)
+			if tt.resolver != nil {
+				parser.SetUserResolver(tt.resolver)
+			}
+			assert.Contains(t, parser.ParseDocxContent(doc, blocks), tt.want)
+		})
+	}
+}
+
+// orderedBlock 构造有序列表项
+func orderedBlock(id, parentID, content string, children ...string) *lark.DocxBlock {
+	return &lark.DocxBlock{
+		BlockID:   id,
+		ParentID:  parentID,
+		BlockType: lark.DocxBlockTypeOrdered,
+		Ordered:   textBlock(content),
+		Children:  children,
+	}
+}
+
+func TestParseDocxBlockOrdered(t *testing.T) {
+	t.Run("long list", func(t *testing.T) {
+		page := &lark.DocxBlock{BlockID: "page", BlockType: lark.DocxBlockTypePage, Page: textBlock("长列表")}
+		blocks := []*lark.DocxBlock{page}
+		for i := 1; i <= 150; i++ {
+			id := fmt.Sprintf("o%d", i)
+			page.Children = append(page.Children, id)
+			blocks = append(blocks, orderedBlock(id, "page", fmt.Sprintf("第 %d 项", i)))
+		}
+		doc, blocks := newTestDocx(blocks...)
+
+		markdown := core.NewParser(core.NewConfig("", "").Output).ParseDocxContent(doc, blocks)
+		assert.Contains(t, markdown, "1. 第 1 项\n")
+		assert.Contains(t, markdown, "99. 第 99 项\n")
+		assert.Contains(t, markdown, "150. 第 150 项\n")
+	})
+
+	t.Run("mixed nesting", func(t *testing.T) {
+		doc, blocks := newTestDocx(
+			&lark.DocxBlock{
+				BlockID:   "page",
+				BlockType: lark.DocxBlockTypePage,
+				Page:      textBlock("嵌套"),
+				Children:  []string{"o1", "o2"},
+			},
+			orderedBlock("o1", "page", "一", "b1"),
+			&lark.DocxBlock{
+				BlockID:   "b1",
+				ParentID:  "o1",
+				BlockType: lark.DocxBlockTypeBullet,
+				Bullet:    textBlock("甲"),
+				Children:  []string{"n1", "n2"},
+			},
+			orderedBlock("n1", "b1", "子一"),
+			orderedBlock("n2", "b1", "子二"),
+			orderedBlock("o2", "page", "二", "n3"),
+			orderedBlock("n3", "o2", "子三"),
+		)
+
+		parser := core.NewParser(core.NewConfig("", "").Output)
+		assert.Equal(t, "# 嵌套\n\n"+
+			"1. 一\n\t- 甲\n\t\t1. 子一\n\t\t2. 子二\n"+
+			"2. 二\n\t1. 子三\n",
+			parser.ParseDocxContent(doc, blocks))
+	})
+
+	restarted := func() (*lark.DocxDocument, []*lark.DocxBlock) {
+		return newTestDocx(
+			&lark.DocxBlock{
+				BlockID:   "page",
+				BlockType: lark.DocxBlockTypePage,
+				Page:      textBlock("编号"),
+				Children:  []string{"o1", "o2", "p1", "o3", "o4", "p2", "o5", "o6", "o7"},
+			},
+			orderedBlock("o1", "page", "a"),
+			orderedBlock("o2", "page", "b"),
+			&lark.DocxBlock{BlockID: "p1", ParentID: "page", BlockType: lark.DocxBlockTypeText, Text: textBlock("插入的段落")},
+			orderedBlock("o3", "page", "c"),
+			orderedBlock("o4", "page", "d"),
+			&lark.DocxBlock{BlockID: "p2", ParentID: "page", BlockType: lark.DocxBlockTypeText, Text: textBlock("另一段")},
+			orderedBlock("o5", "page", "e"),
+			orderedBlock("o6", "page", "f"),
+			orderedBlock("o7", "page", "g"),
+		)
+	}
+	properties := core.DocxBlockProperties{}
+	for id, sequence := range map[string]string{
+		"o1": "1", "o2": "auto", "o3": "auto", "o4": "1",
+		"o5": "1", "o6": "5", "o7": "auto",
+	} {
+		properties[id] = &core.DocxBlockProperty{Sequence: sequence}
+	}
+	restartedMarkdown := "# 编号\n\n" +
+		"1. a\n2. b\n\n插入的段落\n\n3. c\n\n<!-- -->\n\n1. d\n\n另一段\n\n1. e\n\n<!-- -->\n\n5. f\n6. g\n"
+
+	t.Run("restarted lists", func(t *testing.T) {
+		doc, blocks := restarted()
+		parser := core.NewParser(core.NewConfig("", "").Output)
+		parser.SetBlockProperties(properties)
+		markdown := parser.ParseDocxContent(doc, blocks)
+		assert.Equal(t, restartedMarkdown, markdown)
+
+		// 重新编号的列表在格式化后仍保持原有编号
+		engine := lute.New(func(l *lute.Lute) {
+			l.RenderOptions.AutoSpace = true
+		})
+		assert.Equal(t, restartedMarkdown, engine.FormatStr("md", markdown))
+	})
+
+	t.Run("without sequences", func(t *testing.T) {
+		// 无法获取编号属性时，被其他 Block 隔开的列表重新编号
+		doc, blocks := restarted()
+		parser := core.NewParser(core.NewConfig("", "").Output)
+		assert.Equal(t, "# 编号\n\n"+
+			"1. a\n2. b\n\n插入的段落\n\n1. c\n2. d\n\n另一段\n\n1. e\n2. f\n3. g\n",
+			parser.ParseDocxContent(doc, blocks))
+	})
+
+	t.Run("ast", func(t *testing.T) {
+		doc, blocks := restarted()
+		parser := core.NewParser(core.NewConfig("", "").Output)
+		parser.SetBlockProperties(properties)
+		document := parser.BuildDocument(doc, blocks)
+
+		var starts []string
+		for _, b := range document.Children {
+			if b.Type == core.NodeList {
+				starts = append(starts, b.Attrs["start"])
+			}
+		}
+		assert.Equal(t, []string{"1", "3", "1", "1", "5"}, starts)
+		assert.Equal(t, restartedMarkdown,
+			core.NewMarkdownEmitter(core.NewConfig("", "").Output).Emit(document))
+	})
+}
+
+func TestParseDocxTOC(t *testing.T) {
+	link := func(content, url string) *lark.DocxBlockText {
+		return &lark.DocxBlockText{Elements: []*lark.DocxTextElement{{TextRun: &lark.DocxTextElementTextRun{
+			Content:          content,
+			TextElementStyle: &lark.DocxTextElementStyle{Link: &lark.DocxTextElementStyleLink{URL: url}},
+		}}}}
+	}
+	doc, blocks := newTestDocx(
+		&lark.DocxBlock{
+			BlockID:   "page",
+			BlockType: lark.DocxBlockTypePage,
+			Page:      textBlock("目录"),
+			Children:  []string{"h1", "p1", "h3", "h2", "h2b", "p2"},
+		},
+		&lark.DocxBlock{BlockID: "h1", ParentID: "page", BlockType: lark.DocxBlockTypeHeading1, Heading1: textBlock("快速开始")},
+		&lark.DocxBlock{BlockID: "p1", ParentID: "page", BlockType: lark.DocxBlockTypeText,
+			Text: link("见配置", "https://sample.feishu.cn/docx/page%23h2b")},
+		&lark.DocxBlock{BlockID: "h3", ParentID: "page", BlockType: lark.DocxBlockTypeHeading3, Heading3: textBlock("Install & Run")},
+		&lark.DocxBlock{BlockID: "h2", ParentID: "page", BlockType: lark.DocxBlockTypeHeading2, Heading2: textBlock("配置 Config")},
+		&lark.DocxBlock{BlockID: "h2b", ParentID: "page", BlockType: lark.DocxBlockTypeHeading2, Heading2: textBlock("配置 Config")},
+		&lark.DocxBlock{BlockID: "p2", ParentID: "page", BlockType: lark.DocxBlockTypeText,
+			Text: link("其他文档", "https://sample.feishu.cn/docx/other%23doxcnOther")},
+	)
+
+	t.Run("slug", func(t *testing.T) {
+		assert.Equal(t, "快速开始", core.HeadingSlug("快速开始"))
+		assert.Equal(t, "install--run", core.HeadingSlug("Install & Run"))
+		assert.Equal(t, "配置-config", core.HeadingSlug(" 配置 Config "))
+		assert.Equal(t, "v12-版本说明", core.HeadingSlug("v1.2 版本说明！"))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		markdown := core.NewParser(core.NewConfig("", "").Output).ParseDocxContent(doc, blocks)
+		assert.NotContains(t, markdown, "{#")
+		assert.Contains(t, markdown, "[见配置](https://sample.feishu.cn/docx/page#h2b)")
+	})
+
+	t.Run("toc", func(t *testing.T) {
+		config := core.NewConfig("", "").Output
+		config.TOC = true
+		markdown := core.NewParser(config).ParseDocxContent(doc, blocks)
+		assert.True(t, strings.HasPrefix(markdown, "# 目录\n\n"+
+			"- [快速开始](#快速开始)\n"+
+			"\t- [Install & Run](#install--run)\n"+
+			"\t- [配置 Config](#配置-config)\n"+
+			"\t- [配置 Config](#配置-config-1)\n\n"), markdown)
+		assert.Contains(t, markdown, "[见配置](#配置-config-1)")
+		assert.Contains(t, markdown, "[其他文档](https://sample.feishu.cn/docx/other#doxcnOther)")
+		assert.NotContains(t, markdown, "{#")
+	})
+
+	t.Run("heading anchors", func(t *testing.T) {
+		config := core.NewConfig("", "").Output
+		config.HeadingAnchors = true
+		markdown := core.NewParser(config).ParseDocxContent(doc, blocks)
+		assert.Contains(t, markdown, "# 快速开始 {#快速开始}\n")
+		assert.Contains(t, markdown, "### Install & Run {#install--run}\n")
+		assert.Contains(t, markdown, "## 配置 Config {#配置-config-1}\n")
+		assert.Contains(t, markdown, "[见配置](#配置-config-1)")
+		assert.NotContains(t, markdown, "- [快速开始]")
+	})
+
+	t.Run("ast", func(t *testing.T) {
+		config := core.NewConfig("", "").Output
+		config.TOC = true
+		config.HeadingAnchors = true
+		document := core.NewParser(config).BuildDocument(doc, blocks)
+		assert.Equal(t, "配置-config-1", document.Children[4].Attrs["anchor"])
+
+		markdown := core.NewMarkdownEmitter(config).Emit(document)
+		assert.True(t, strings.HasPrefix(markdown, "# 目录\n\n- [快速开始](#快速开始)\n\t- [Install & Run](#install--run)\n"), markdown)
+		assert.Contains(t, markdown, "## 配置 Config {#配置-config}\n")
+		assert.Contains(t, markdown, "[见配置](#配置-config-1)")
+
+		html := core.NewHTMLEmitter(config).Emit(document)
+		assert.Contains(t, html, `<nav class="toc">`+"\n<ul>\n"+
+			`<li><a href="#快速开始">快速开始</a>`+"\n<ul>\n"+
+			`<li><a href="#install--run">Install &amp; Run</a>`+"</li>\n"+
+			`<li><a href="#配置-config">配置 Config</a>`+"</li>\n"+
+			`<li><a href="#配置-config-1">配置 Config</a>`+"</li>\n</ul>\n</li>\n</ul>\n</nav>\n")
+		assert.Contains(t, html, `<h2 id="配置-config-1">配置 Config</h2>`)
+		assert.Contains(t, html, `<a href="#配置-config-1">见配置</a>`)
+	})
+}
+
+func TestParseDocxBlockImage(t *testing.T) {
+	doc, blocks := newTestDocx(
+		&lark.DocxBlock{
+			BlockID:   "page",
+			BlockType: lark.DocxBlockTypePage,
+			Page:      textBlock("图片"),
+			Children:  []string{"img1", "img2", "img3"},
+		},
+		&lark.DocxBlock{BlockID: "img1", ParentID: "page", BlockType: lark.DocxBlockTypeImage,
+			Image: &lark.DocxBlockImage{Token: "boxcnA", Width: 640, Height: 480}},
+		&lark.DocxBlock{BlockID: "img2", ParentID: "page", BlockType: lark.DocxBlockTypeImage,
+			Image: &lark.DocxBlockImage{Token: "boxcnB"}},
+		&lark.DocxBlock{BlockID: "img3", ParentID: "page", BlockType: lark.DocxBlockTypeImage,
+			Image: &lark.DocxBlockImage{Token: "boxcnA"}},
+	)
+	properties := core.DocxBlockProperties{
+		"img1": {Align: lark.DocxAlignCenter, Caption: `架构图 [v2] "草稿"`},
+		"img2": {Align: lark.DocxAlignLeft},
+		"img3": {Caption: "复用的图片"},
+	}
+
+	t.Run("markdown", func(t *testing.T) {
+		parser := core.NewParser(core.NewConfig("", "").Output)
+		parser.SetBlockProperties(properties)
+		markdown := parser.ParseDocxContent(doc, blocks)
+		assert.Contains(t, markdown, `![架构图 \[v2\] "草稿"](boxcnA "架构图 [v2] \"草稿\"")`+"\n")
+		assert.Contains(t, markdown, "![](boxcnB)\n")
+		// 同一图片在不同 Block 中保留各自的图片描述
+		assert.Contains(t, markdown, `![复用的图片](boxcnA "复用的图片")`+"\n")
+	})
+
+	t.Run("without properties", func(t *testing.T) {
+		parser := core.NewParser(core.NewConfig("", "").Output)
+		markdown := parser.ParseDocxContent(doc, blocks)
+		assert.Contains(t, markdown, "![](boxcnA)\n")
+		assert.Equal(t, []string{"boxcnA", "boxcnB", "boxcnA"}, parser.ImgTokens)
+	})
+
+	t.Run("html", func(t *testing.T) {
+		config := core.NewConfig("", "").Output
+		config.ImageMode = core.ImageModeHTML
+		parser := core.NewParser(config)
+		parser.SetBlockProperties(properties)
+		markdown := parser.ParseDocxContent(doc, blocks)
+		assert.Contains(t, markdown, `<p align="center"><img src="boxcnA" alt="架构图 [v2] &#34;草稿&#34;" `+
+			`title="架构图 [v2] &#34;草稿&#34;" width="640" height="480"></p>`+"\n")
+		assert.Contains(t, markdown, `<img src="boxcnB" alt="">`+"\n")
+
+		// use_html_tags 同样输出为 <img>
+		config = core.NewConfig("", "").Output
+		config.UseHTMLTags = true
+		assert.Contains(t, core.NewParser(config).ParseDocxContent(doc, blocks),
+			`<img src="boxcnA" alt="" width="640" height="480">`)
+	})
+
+	t.Run("ast", func(t *testing.T) {
+		config := core.NewConfig("", "").Output
+		parser := core.NewParser(config)
+		parser.SetBlockProperties(properties)
+		document := parser.BuildDocument(doc, blocks)
+		assert.Equal(t, map[string]string{
+			"token": "boxcnA", "width": "640", "height": "480", "align": "center", "caption": `架构图 [v2] "草稿"`,
+		}, document.Children[0].Attrs)
+		assert.Contains(t, core.NewMarkdownEmitter(config).Emit(document), `![架构图 \[v2\] "草稿"](boxcnA "架构图 [v2] \"草稿\"")`)
+		assert.Contains(t, core.NewHTMLEmitter(config).Emit(document),
+			`<p style="text-align:center"><img src="boxcnA" width="640" height="480" alt="架构图 [v2] &#34;草稿&#34;" title="架构图 [v2] &#34;草稿&#34;"></p>`)
+	})
+}
+
+func TestParseDocxAssetResolver(t *testing.T) {
+	doc, blocks := newTestDocx(
+		&lark.DocxBlock{
+			BlockID:   "page",
+			BlockType: lark.DocxBlockTypePage,
+			Page:      textBlock("资源"),
+			Children:  []string{"text", "img1", "img2", "board", "file"},
+		},
+		// 正文中出现的 token 不会被替换
+		&lark.DocxBlock{BlockID: "text", ParentID: "page", BlockType: lark.DocxBlockTypeText, Text: textBlock("图片 boxcnA 出现了两次")},
+		&lark.DocxBlock{BlockID: "img1", ParentID: "page", BlockType: lark.DocxBlockTypeImage, Image: &lark.DocxBlockImage{Token: "boxcnA"}},
+		&lark.DocxBlock{BlockID: "img2", ParentID: "page", BlockType: lark.DocxBlockTypeImage, Image: &lark.DocxBlockImage{Token: "boxcnA"}},
+		&lark.DocxBlock{BlockID: "board", ParentID: "page", BlockType: core.DocxBlockTypeBoard},
+		&lark.DocxBlock{BlockID: "file", ParentID: "page", BlockType: lark.DocxBlockTypeFile, File: &lark.DocxBlockFile{Token: "boxcnF", Name: "报告.pdf"}},
+	)
+	resolver := func(assets *[]string) core.AssetResolver {
+		return func(asset *core.Asset) string {
+			*assets = append(*assets, asset.Kind+":"+asset.Token+":"+asset.Name)
+			return fmt.Sprintf("static/%s %d.png", asset.Token, len(*assets))
+		}
+	}
+
+	t.Run("markdown", func(t *testing.T) {
+		var assets []string
+		parser := core.NewParser(core.NewConfig("", "").Output)
+		parser.SetAssetResolver(resolver(&assets))
+		assert.Equal(t, "# 资源\n\n"+
+			"图片 boxcnA 出现了两次\n\n"+
+			"![](static/boxcnA%201.png)\n\n"+
+			"![](static/boxcnA%202.png)\n\n"+
+			"![](static/board%203.png)\n\n"+
+			"[报告.pdf](static/boxcnF%204.png)\n",
+			parser.ParseDocxContent(doc, blocks))
+		assert.Equal(t, []string{"image:boxcnA:", "image:boxcnA:", "board:board:", "file:boxcnF:报告.pdf"}, assets)
+	})
+
+	t.Run("ast", func(t *testing.T) {
+		var assets []string
+		config := core.NewConfig("", "").Output
+		parser := core.NewParser(config)
+		parser.SetAssetResolver(resolver(&assets))
+		document := parser.BuildDocument(doc, blocks)
+		assert.Equal(t, "boxcnA", document.Children[1].Attrs["token"])
+		assert.Equal(t, "static/boxcnA 1.png", document.Children[1].Attrs["src"])
+
+		html := core.NewHTMLEmitter(config).Emit(document)
+		assert.Contains(t, html, `<img src="static/boxcnA 2.png" alt="">`)
+		assert.Contains(t, html, `<a href="static/boxcnF 4.png">报告.pdf</a>`)
+		assert.Contains(t, core.NewMarkdownEmitter(config).Emit(document), "![](static/board%203.png)\n")
+	})
+
+	t.Run("without resolver", func(t *testing.T) {
+		parser := core.NewParser(core.NewConfig("", "").Output)
+		markdown := parser.ParseDocxContent(doc, blocks)
+		assert.Contains(t, markdown, "![](boxcnA)\n\n![](boxcnA)\n")
+		assert.Contains(t, markdown, "[报告.pdf](boxcnF)\n")
+	})
+}
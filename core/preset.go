@@ -0,0 +1,328 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Wsine/feishu2md/utils"
+)
+
+// Preset 静态站点生成器的输出约定，包括文件命名、front matter 字段、图片位置和导航文件
+type Preset struct {
+	Name string
+	// IndexFileName 知识库中有子节点的文档保存为子目录下的该文件，为空时与普通文档一样保存在当前目录
+	IndexFileName string
+	// FolderNote 有子节点的文档以标题命名并保存在其子目录中，即 Obsidian 的 folder note
+	FolderNote bool
+	// FrontMatter 默认的 front matter 格式，配置中已指定格式时不覆盖
+	FrontMatter string
+	// ImageDir 图片目录，相对于文档所在目录
+	ImageDir string
+	// Flavor Markdown 方言，为空时不修改配置
+	Flavor string
+	// ColorMode 默认的文字颜色输出方式，配置中已指定时不覆盖
+	ColorMode string
+	// FrontMatterFields 生成 front matter 字段
+	FrontMatterFields func(info *DocumentInfo) []FrontMatterField
+	// WriteNav 根据知识库的文档树在 root 目录生成导航文件，为 nil 时不生成
+	WriteNav func(root string, nav []*NavNode) error
+}
+
+// NavNode 导航中的一个文档或目录
+type NavNode struct {
+	Title    string
+	Path     string // 相对站点根目录的 Markdown 文件路径，没有对应文档时为空
+	Dir      string // 子节点所在的目录，没有子节点时为空
+	Position int    // 在同级节点中的位置，从 1 开始
+	Children []*NavNode
+}
+
+var presets = map[string]*Preset{
+	"hugo": {
+		Name:          "hugo",
+		IndexFileName: "_index.md",
+		FrontMatter:   FrontMatterYAML,
+		ImageDir:      "images",
+		FrontMatterFields: func(info *DocumentInfo) []FrontMatterField {
+			return []FrontMatterField{
+				{"title", info.Title},
+				{"date", info.CreatedAt},
+				{"lastmod", info.UpdatedAt},
+				{"weight", info.Position},
+				{"author", info.Owner},
+			}
+		},
+	},
+	"hexo": {
+		Name:        "hexo",
+		FrontMatter: FrontMatterYAML,
+		ImageDir:    "images",
+		FrontMatterFields: func(info *DocumentInfo) []FrontMatterField {
+			return []FrontMatterField{
+				{"title", info.Title},
+				{"date", info.CreatedAt},
+				{"updated", info.UpdatedAt},
+				{"author", info.Owner},
+			}
+		},
+	},
+	"docusaurus": {
+		Name:          "docusaurus",
+		IndexFileName: "index.md",
+		FrontMatter:   FrontMatterYAML,
+		ImageDir:      "assets",
+		FrontMatterFields: func(info *DocumentInfo) []FrontMatterField {
+			return []FrontMatterField{
+				{"title", info.Title},
+				{"sidebar_position", info.Position},
+			}
+		},
+		WriteNav: writeDocusaurusCategories,
+	},
+	"mkdocs": {
+		Name:          "mkdocs",
+		IndexFileName: "index.md",
+		FrontMatter:   FrontMatterYAML,
+		ImageDir:      "assets",
+		FrontMatterFields: func(info *DocumentInfo) []FrontMatterField {
+			return []FrontMatterField{
+				{"title", info.Title},
+				{"date", info.UpdatedAt},
+			}
+		},
+		WriteNav: writeMkDocsNav,
+	},
+	"vitepress": {
+		Name:          "vitepress",
+		IndexFileName: "index.md",
+		FrontMatter:   FrontMatterYAML,
+		ImageDir:      "assets",
+		FrontMatterFields: func(info *DocumentInfo) []FrontMatterField {
+			return []FrontMatterField{
+				{"title", info.Title},
+			}
+		},
+		WriteNav: writeVitePressSidebar,
+	},
+	"obsidian": {
+		Name:        "obsidian",
+		FolderNote:  true,
+		FrontMatter: FrontMatterYAML,
+		ImageDir:    "attachments",
+		Flavor:      FlavorObsidian,
+		ColorMode:   ColorModeObsidian,
+		FrontMatterFields: func(info *DocumentInfo) []FrontMatterField {
+			return []FrontMatterField{
+				{"created", info.CreatedAt},
+				{"updated", info.UpdatedAt},
+				{"source", info.SourceURL},
+				{"author", info.Owner},
+			}
+		},
+	},
+}
+
+// presetsMutex 保护 presets，注册预设与下载文档时读取预设可能并发进行
+var presetsMutex sync.RWMutex
+
+// RegisterPreset 注册或覆盖预设，可与 GetPreset 等并发调用
+func RegisterPreset(p *Preset) {
+	presetsMutex.Lock()
+	defer presetsMutex.Unlock()
+
+	presets[p.Name] = p
+}
+
+// lookupPreset 按名称查找已注册的预设
+func lookupPreset(name string) (*Preset, bool) {
+	presetsMutex.RLock()
+	defer presetsMutex.RUnlock()
+
+	p, ok := presets[name]
+	return p, ok
+}
+
+// GetPreset 按名称获取预设
+func GetPreset(name string) (*Preset, error) {
+	p, ok := lookupPreset(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown preset: %s, available presets: %s", name, strings.Join(PresetNames(), ", "))
+	}
+	return p, nil
+}
+
+// PresetNames 返回已注册的预设名称
+func PresetNames() []string {
+	presetsMutex.RLock()
+	defer presetsMutex.RUnlock()
+
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Apply 将预设的输出约定应用到输出配置
+func (p *Preset) Apply(output *OutputConfig) {
+	output.Preset = p.Name
+	output.TitleAsFilename = true
+	if output.FrontMatter == "" || output.FrontMatter == FrontMatterNone {
+		output.FrontMatter = p.FrontMatter
+	}
+	if p.ImageDir != "" {
+		output.ImageDir = p.ImageDir
+	}
+	if p.Flavor != "" {
+		output.Flavor = p.Flavor
+	}
+	if p.ColorMode != "" && (output.ColorMode == "" || output.ColorMode == ColorModeNone) {
+		output.ColorMode = p.ColorMode
+	}
+}
+
+// IndexInFolder 有子节点的文档是否保存在其子目录中
+func (p *Preset) IndexInFolder() bool {
+	return p.IndexFileName != "" || p.FolderNote
+}
+
+// DocFileName 返回文档的文件名，index 表示该文档是有子节点的目录文档
+func (p *Preset) DocFileName(title string, index bool) string {
+	if index && p.IndexFileName != "" {
+		return p.IndexFileName
+	}
+	return utils.SanitizeFileName(title) + ".md"
+}
+
+// BuildFrontMatterFields 按输出配置中的预设生成 front matter 字段，未使用预设时为默认字段
+func BuildFrontMatterFields(output OutputConfig, info *DocumentInfo) []FrontMatterField {
+	p, ok := lookupPreset(output.Preset)
+	if !ok || p.FrontMatterFields == nil {
+		return DefaultFrontMatterFields(info, output.FrontMatterFields)
+	}
+	return appendExtraFields(p.FrontMatterFields(info), output.FrontMatterFields)
+}
+
+// writeDocusaurusCategories 为每个目录生成 _category_.json，设置侧边栏中的名称和顺序
+func writeDocusaurusCategories(root string, nav []*NavNode) error {
+	for _, node := range nav {
+		if node.Dir == "" {
+			continue
+		}
+		data, err := json.MarshalIndent(map[string]interface{}{
+			"label":    node.Title,
+			"position": node.Position,
+		}, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Join(root, node.Dir), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(root, node.Dir, "_category_.json"), data, 0o644); err != nil {
+			return err
+		}
+		if err := writeDocusaurusCategories(root, node.Children); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeMkDocsNav 在 root 的上级目录生成 mkdocs.yml，已存在时只替换其中的 nav 部分
+func writeMkDocsNav(root string, nav []*NavNode) error {
+	buf := new(strings.Builder)
+	buf.WriteString("nav:\n")
+	writeMkDocsNavItems(buf, nav, 1)
+
+	configPath := filepath.Join(filepath.Dir(root), "mkdocs.yml")
+	content, err := os.ReadFile(configPath)
+	if os.IsNotExist(err) {
+		content = []byte(fmt.Sprintf("site_name: %s\ndocs_dir: %s\n",
+			quoteFrontMatterString(filepath.Base(root)), quoteFrontMatterString(filepath.Base(root))))
+	} else if err != nil {
+		return err
+	}
+	return os.WriteFile(configPath, []byte(replaceYAMLSection(string(content), "nav", buf.String())), 0o644)
+}
+
+func writeMkDocsNavItems(buf *strings.Builder, nav []*NavNode, depth int) {
+	indent := strings.Repeat("  ", depth)
+	for _, node := range nav {
+		title := quoteFrontMatterString(node.Title)
+		if len(node.Children) == 0 {
+			buf.WriteString(fmt.Sprintf("%s- %s: %s\n", indent, title, quoteFrontMatterString(node.Path)))
+			continue
+		}
+		buf.WriteString(fmt.Sprintf("%s- %s:\n", indent, title))
+		if node.Path != "" {
+			buf.WriteString(fmt.Sprintf("%s    - %s\n", indent, quoteFrontMatterString(node.Path)))
+		}
+		writeMkDocsNavItems(buf, node.Children, depth+2)
+	}
+}
+
+// replaceYAMLSection 替换 YAML 中的顶层键 key 及其内容，不存在时追加到末尾
+func replaceYAMLSection(content, key, section string) string {
+	lines := strings.SplitAfter(content, "\n")
+	start, end := -1, len(lines)
+	for i, line := range lines {
+		if start < 0 {
+			if strings.HasPrefix(line, key+":") {
+				start = i
+			}
+			continue
+		}
+		if line != "" && line != "\n" && !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "-") {
+			end = i
+			break
+		}
+	}
+	if start < 0 {
+		if content != "" && !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
+		return content + section
+	}
+	return strings.Join(lines[:start], "") + section + strings.Join(lines[end:], "")
+}
+
+type vitePressSidebarItem struct {
+	Text  string                  `json:"text"`
+	Link  string                  `json:"link,omitempty"`
+	Items []*vitePressSidebarItem `json:"items,omitempty"`
+}
+
+// writeVitePressSidebar 生成 .vitepress/sidebar.json，可在 config 中导入作为 themeConfig.sidebar
+func writeVitePressSidebar(root string, nav []*NavNode) error {
+	data, err := json.MarshalIndent(vitePressSidebarItems(nav), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Join(root, ".vitepress"), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(root, ".vitepress", "sidebar.json"), data, 0o644)
+}
+
+func vitePressSidebarItems(nav []*NavNode) []*vitePressSidebarItem {
+	items := make([]*vitePressSidebarItem, 0, len(nav))
+	for _, node := range nav {
+		item := &vitePressSidebarItem{Text: node.Title}
+		if node.Path != "" {
+			link := "/" + strings.TrimSuffix(node.Path, ".md")
+			item.Link = strings.TrimSuffix(link, "index")
+		}
+		if len(node.Children) > 0 {
+			item.Items = vitePressSidebarItems(node.Children)
+		}
+		items = append(items, item)
+	}
+	return items
+}
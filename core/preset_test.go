@@ -0,0 +1,130 @@
+package core_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Wsine/feishu2md/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetPreset(t *testing.T) {
+	assert.Equal(t, []string{"docusaurus", "hexo", "hugo", "mkdocs", "obsidian", "vitepress"}, core.PresetNames())
+
+	_, err := core.GetPreset("jekyll")
+	assert.Error(t, err)
+
+	preset, err := core.GetPreset("hugo")
+	assert.NoError(t, err)
+	output := core.NewConfig("", "").Output
+	preset.Apply(&output)
+	assert.Equal(t, "hugo", output.Preset)
+	assert.True(t, output.TitleAsFilename)
+	assert.Equal(t, core.FrontMatterYAML, output.FrontMatter)
+	assert.Equal(t, "images", output.ImageDir)
+	assert.Equal(t, "_index.md", preset.DocFileName("指南", true))
+	assert.Equal(t, "指南.md", preset.DocFileName("指南", false))
+
+	// 已指定的 front matter 格式不被覆盖
+	output.FrontMatter = core.FrontMatterTOML
+	preset.Apply(&output)
+	assert.Equal(t, core.FrontMatterTOML, output.FrontMatter)
+
+	preset, err = core.GetPreset("obsidian")
+	assert.NoError(t, err)
+	output = core.NewConfig("", "").Output
+	preset.Apply(&output)
+	assert.Equal(t, core.FlavorObsidian, output.Flavor)
+	assert.Equal(t, core.ColorModeObsidian, output.ColorMode)
+	assert.True(t, preset.IndexInFolder())
+	assert.Equal(t, "指南.md", preset.DocFileName("指南", true))
+}
+
+func TestBuildFrontMatterFields(t *testing.T) {
+	info := &core.DocumentInfo{
+		Title:     "指南",
+		Token:     "doxcnToken",
+		CreatedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Position:  3,
+	}
+	output := core.OutputConfig{
+		FrontMatter:       core.FrontMatterYAML,
+		Preset:            "hugo",
+		FrontMatterFields: map[string]interface{}{"draft": false},
+	}
+	assert.Equal(t, "---\n"+
+		"title: \"指南\"\n"+
+		"date: 2024-01-02T03:04:05Z\n"+
+		"weight: 3\n"+
+		"draft: false\n"+
+		"---\n\n",
+		core.RenderFrontMatter(output.FrontMatter, core.BuildFrontMatterFields(output, info)))
+
+	output.Preset = "docusaurus"
+	assert.Equal(t, "---\n"+
+		"title: \"指南\"\n"+
+		"sidebar_position: 3\n"+
+		"draft: false\n"+
+		"---\n\n",
+		core.RenderFrontMatter(output.FrontMatter, core.BuildFrontMatterFields(output, info)))
+}
+
+var testNav = []*core.NavNode{
+	{Title: "介绍", Path: "介绍.md", Position: 1},
+	{Title: "指南", Path: "指南/index.md", Dir: "指南", Position: 2, Children: []*core.NavNode{
+		{Title: "安装: 快速", Path: "指南/安装: 快速.md", Position: 1},
+	}},
+}
+
+func TestWriteMkDocsNav(t *testing.T) {
+	preset, err := core.GetPreset("mkdocs")
+	assert.NoError(t, err)
+	root := filepath.Join(t.TempDir(), "docs")
+
+	assert.NoError(t, preset.WriteNav(root, testNav))
+	content, err := os.ReadFile(filepath.Join(filepath.Dir(root), "mkdocs.yml"))
+	assert.NoError(t, err)
+	nav := "nav:\n" +
+		"  - \"介绍\": \"介绍.md\"\n" +
+		"  - \"指南\":\n" +
+		"      - \"指南/index.md\"\n" +
+		"      - \"安装: 快速\": \"指南/安装: 快速.md\"\n"
+	assert.Equal(t, "site_name: \"docs\"\ndocs_dir: \"docs\"\n"+nav, string(content))
+
+	// 已有的配置只替换 nav 部分
+	existing := "site_name: 我的站点\nnav:\n  - 旧: old.md\ntheme:\n  name: material\n"
+	assert.NoError(t, os.WriteFile(filepath.Join(filepath.Dir(root), "mkdocs.yml"), []byte(existing), 0o644))
+	assert.NoError(t, preset.WriteNav(root, testNav))
+	content, err = os.ReadFile(filepath.Join(filepath.Dir(root), "mkdocs.yml"))
+	assert.NoError(t, err)
+	assert.Equal(t, "site_name: 我的站点\n"+nav+"theme:\n  name: material\n", string(content))
+}
+
+func TestWriteDocusaurusCategories(t *testing.T) {
+	preset, err := core.GetPreset("docusaurus")
+	assert.NoError(t, err)
+	root := t.TempDir()
+
+	assert.NoError(t, preset.WriteNav(root, testNav))
+	content, err := os.ReadFile(filepath.Join(root, "指南", "_category_.json"))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"label": "指南", "position": 2}`, string(content))
+}
+
+func TestWriteVitePressSidebar(t *testing.T) {
+	preset, err := core.GetPreset("vitepress")
+	assert.NoError(t, err)
+	root := t.TempDir()
+
+	assert.NoError(t, preset.WriteNav(root, testNav))
+	content, err := os.ReadFile(filepath.Join(root, ".vitepress", "sidebar.json"))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `[
+		{"text": "介绍", "link": "/介绍"},
+		{"text": "指南", "link": "/指南/", "items": [
+			{"text": "安装: 快速", "link": "/指南/安装: 快速"}
+		]}
+	]`, string(content))
+}
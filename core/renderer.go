@@ -0,0 +1,120 @@
+package core
+
+import (
+	"sync"
+
+	"github.com/chyroc/lark"
+)
+
+// BlockRenderer 将一种类型的 Block 构建为 AST 节点，Markdown 和 HTML 均由构建出的 AST 输出。
+// 返回 nil 表示忽略该 Block，构建子 Block 时可调用 Parser.BuildBlocks
+type BlockRenderer interface {
+	RenderBlock(p *Parser, b *lark.DocxBlock) []*Block
+}
+
+// BlockRendererFunc 以函数实现 BlockRenderer
+type BlockRendererFunc func(p *Parser, b *lark.DocxBlock) []*Block
+
+func (f BlockRendererFunc) RenderBlock(p *Parser, b *lark.DocxBlock) []*Block {
+	return f(p, b)
+}
+
+// SkipBlockRenderer 忽略 Block，不记录诊断信息
+var SkipBlockRenderer BlockRenderer = BlockRendererFunc(func(p *Parser, b *lark.DocxBlock) []*Block {
+	return nil
+})
+
+// defaultBlockRenderers 内置的 Block 渲染器，新建 Parser 时复制一份。
+// 无序列表、有序列表和待办事项构建为列表项，相邻的列表项由 Parser.BuildBlocks 合并为列表
+var defaultBlockRenderers = map[lark.DocxBlockType]BlockRenderer{
+	lark.DocxBlockTypePage:           BlockRendererFunc((*Parser).buildPage),
+	lark.DocxBlockTypeText:           BlockRendererFunc((*Parser).buildText),
+	lark.DocxBlockTypeHeading1:       BlockRendererFunc((*Parser).buildHeading),
+	lark.DocxBlockTypeHeading2:       BlockRendererFunc((*Parser).buildHeading),
+	lark.DocxBlockTypeHeading3:       BlockRendererFunc((*Parser).buildHeading),
+	lark.DocxBlockTypeHeading4:       BlockRendererFunc((*Parser).buildHeading),
+	lark.DocxBlockTypeHeading5:       BlockRendererFunc((*Parser).buildHeading),
+	lark.DocxBlockTypeHeading6:       BlockRendererFunc((*Parser).buildHeading),
+	lark.DocxBlockTypeHeading7:       BlockRendererFunc((*Parser).buildHeading),
+	lark.DocxBlockTypeHeading8:       BlockRendererFunc((*Parser).buildHeading),
+	lark.DocxBlockTypeHeading9:       BlockRendererFunc((*Parser).buildHeading),
+	lark.DocxBlockTypeBullet:         BlockRendererFunc((*Parser).buildListItem),
+	lark.DocxBlockTypeOrdered:        BlockRendererFunc((*Parser).buildListItem),
+	lark.DocxBlockTypeTodo:           BlockRendererFunc((*Parser).buildListItem),
+	lark.DocxBlockTypeCode:           BlockRendererFunc((*Parser).buildCode),
+	lark.DocxBlockTypeQuote:          BlockRendererFunc((*Parser).buildQuote),
+	lark.DocxBlockTypeQuoteContainer: BlockRendererFunc((*Parser).buildQuoteContainer),
+	lark.DocxBlockTypeCallout:        BlockRendererFunc((*Parser).buildCallout),
+	lark.DocxBlockTypeEquation:       BlockRendererFunc((*Parser).buildEquation),
+	lark.DocxBlockTypeDivider:        BlockRendererFunc((*Parser).buildDivider),
+	lark.DocxBlockTypeImage:          BlockRendererFunc((*Parser).buildImage),
+	DocxBlockTypeBoard:               BlockRendererFunc((*Parser).buildBoard),
+	lark.DocxBlockTypeDiagram:        BlockRendererFunc((*Parser).buildBoard),
+	lark.DocxBlockTypeFile:           BlockRendererFunc((*Parser).buildFile),
+	lark.DocxBlockTypeSheet:          BlockRendererFunc((*Parser).buildSheet),
+	lark.DocxBlockTypeBitable:        BlockRendererFunc((*Parser).buildBitable),
+	lark.DocxBlockTypeTable:          BlockRendererFunc((*Parser).buildTable),
+	lark.DocxBlockTypeView:           BlockRendererFunc((*Parser).buildChildren),
+	lark.DocxBlockTypeGridColumn:     BlockRendererFunc((*Parser).buildChildren),
+	lark.DocxBlockTypeGrid:           BlockRendererFunc((*Parser).buildGrid),
+}
+
+// blockRenderersMutex 保护 defaultBlockRenderers，注册渲染器与新建 Parser 可能并发进行
+var blockRenderersMutex sync.RWMutex
+
+// RegisterBlockRenderer 为之后新建的所有 Parser 注册或覆盖 blockType 的渲染器，可与 NewParser 并发调用
+func RegisterBlockRenderer(blockType lark.DocxBlockType, renderer BlockRenderer) {
+	blockRenderersMutex.Lock()
+	defer blockRenderersMutex.Unlock()
+
+	defaultBlockRenderers[blockType] = renderer
+}
+
+// copyBlockRenderers 复制一份已注册的渲染器，供新建的 Parser 使用
+func copyBlockRenderers() map[lark.DocxBlockType]BlockRenderer {
+	blockRenderersMutex.RLock()
+	defer blockRenderersMutex.RUnlock()
+
+	return mergeDefaults(defaultBlockRenderers, nil)
+}
+
+// SetBlockRenderer 为当前 Parser 注册或覆盖 blockType 的渲染器，renderer 为 nil 时移除
+func (p *Parser) SetBlockRenderer(blockType lark.DocxBlockType, renderer BlockRenderer) {
+	if renderer == nil {
+		delete(p.renderers, blockType)
+		return
+	}
+	p.renderers[blockType] = renderer
+}
+
+// SetFallbackRenderer 设置未注册类型的 Block 的渲染器，默认为 UnsupportedBlockRenderer
+func (p *Parser) SetFallbackRenderer(renderer BlockRenderer) {
+	if renderer == nil {
+		renderer = UnsupportedBlockRenderer
+	}
+	p.fallbackRenderer = renderer
+}
+
+// BlockByID 按 ID 查找当前文档中的 Block，供自定义渲染器构建子 Block
+func (p *Parser) BlockByID(blockID string) *lark.DocxBlock {
+	return p.blockMap[blockID]
+}
+
+// BuildBlocks 构建 ids 对应的 Block 节点，供自定义渲染器构建子 Block
+func (p *Parser) BuildBlocks(ids []string) []*Block {
+	return p.buildBlocks(ids)
+}
+
+// BuildInlines 构建文本的行内节点，供自定义渲染器使用
+func (p *Parser) BuildInlines(text *lark.DocxBlockText) []*Inline {
+	return p.buildInlines(text)
+}
+
+// renderBlock 按注册的渲染器构建 Block，未注册的类型交给 fallbackRenderer
+func (p *Parser) renderBlock(b *lark.DocxBlock) []*Block {
+	renderer, ok := p.renderers[b.BlockType]
+	if !ok {
+		renderer = p.fallbackRenderer
+	}
+	return renderer.RenderBlock(p, b)
+}
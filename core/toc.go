@@ -0,0 +1,161 @@
+package core
+
+import (
+	"fmt"
+	"html"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/chyroc/lark"
+)
+
+// TOCEntry 目录中的一个标题
+type TOCEntry struct {
+	Level  int
+	Text   string
+	Anchor string
+}
+
+// anchorLinkRegex 匹配带有锚点的飞书文档链接，分组为锚点中的 Block ID
+var anchorLinkRegex = regexp.MustCompile(`^https?://[\w-.]+/(?:docx|docs|wiki)/[a-zA-Z0-9]+[^#\s]*#([a-zA-Z0-9_-]+)$`)
+
+// HeadingSlug 按 GitHub 的规则生成标题锚点：转为小写，去掉标点符号，空格替换为 -，中文等文字保持不变
+func HeadingSlug(text string) string {
+	buf := new(strings.Builder)
+	for _, r := range strings.ToLower(strings.TrimSpace(text)) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsMark(r) || unicode.IsDigit(r) || r == '-' || r == '_':
+			buf.WriteRune(r)
+		case r == ' ':
+			buf.WriteRune('-')
+		}
+	}
+	return buf.String()
+}
+
+// headingSlugs 为文档中的标题分配不重复的锚点，重名的标题依次加上 -1、-2 后缀
+type headingSlugs map[string]bool
+
+func (s headingSlugs) unique(text string) string {
+	base := HeadingSlug(text)
+	if base == "" {
+		base = "section"
+	}
+	slug := base
+	for n := 1; s[slug]; n++ {
+		slug = fmt.Sprintf("%s-%d", base, n)
+	}
+	s[slug] = true
+	return slug
+}
+
+// loadHeadings 按文档顺序为所有标题生成锚点，仅在开启 toc 或 heading_anchors 时执行
+func (p *Parser) loadHeadings() {
+	p.anchors = map[string]string{}
+	if !p.toc && !p.headingAnchors {
+		return
+	}
+	slugs := headingSlugs{}
+	var walk func(id string)
+	walk = func(id string) {
+		b := p.blockMap[id]
+		if b == nil {
+			return
+		}
+		if b.BlockType >= lark.DocxBlockTypeHeading1 && b.BlockType <= lark.DocxBlockTypeHeading9 {
+			level := int(b.BlockType-lark.DocxBlockTypeHeading1) + 1
+			text := reflect.ValueOf(b).Elem().FieldByName(fmt.Sprintf("Heading%d", level))
+			p.anchors[b.BlockID] = slugs.unique(strings.TrimSpace(PlainText(p.buildInlines(text.Interface().(*lark.DocxBlockText)))))
+		}
+		for _, child := range b.Children {
+			walk(child)
+		}
+	}
+	walk(p.documentID)
+}
+
+// rewriteAnchorLink 将指向本文档标题的飞书链接（如 …/docx/xxx#doxcnXXX）改写为标题锚点，其余链接保持不变
+func (p *Parser) rewriteAnchorLink(href string) string {
+	match := anchorLinkRegex.FindStringSubmatch(href)
+	if match == nil {
+		return href
+	}
+	if anchor, ok := p.anchors[match[1]]; ok {
+		return "#" + anchor
+	}
+	return href
+}
+
+// tocEntries 按文档顺序收集 AST 中带有锚点的标题
+func tocEntries(blocks []*Block) []*TOCEntry {
+	var entries []*TOCEntry
+	for _, b := range blocks {
+		if b.Type == NodeHeading && b.Attrs["anchor"] != "" {
+			level := 1
+			fmt.Sscan(b.Attrs["level"], &level)
+			entries = append(entries, &TOCEntry{Level: level, Text: strings.TrimSpace(PlainText(b.Inlines)), Anchor: b.Attrs["anchor"]})
+		}
+		entries = append(entries, tocEntries(b.Children)...)
+	}
+	return entries
+}
+
+// tocDepths 返回各标题在目录中的缩进层级，最高级的标题为 0，跳级的标题只比上一项多缩进一级
+func tocDepths(entries []*TOCEntry) []int {
+	minLevel := 0
+	for _, entry := range entries {
+		if minLevel == 0 || entry.Level < minLevel {
+			minLevel = entry.Level
+		}
+	}
+	depths := make([]int, len(entries))
+	previous := -1
+	for i, entry := range entries {
+		depths[i] = min(entry.Level-minLevel, previous+1)
+		previous = depths[i]
+	}
+	return depths
+}
+
+// RenderMarkdownTOC 将目录输出为嵌套的 Markdown 列表，没有标题时返回空字符串
+func RenderMarkdownTOC(entries []*TOCEntry) string {
+	buf := new(strings.Builder)
+	for i, depth := range tocDepths(entries) {
+		buf.WriteString(strings.Repeat("\t", depth))
+		buf.WriteString(fmt.Sprintf("- [%s](#%s)\n", escapeMarkdown(entries[i].Text, false, ""), escapeLinkPath(entries[i].Anchor)))
+	}
+	return buf.String()
+}
+
+// renderHTMLTOC 将目录输出为嵌套的 <ul>，没有标题时返回空字符串
+func renderHTMLTOC(entries []*TOCEntry) string {
+	if len(entries) == 0 {
+		return ""
+	}
+	buf := new(strings.Builder)
+	buf.WriteString(`<nav class="toc">` + "\n")
+	depth := -1
+	for i, d := range tocDepths(entries) {
+		if d > depth {
+			if depth >= 0 {
+				buf.WriteString("\n")
+			}
+			buf.WriteString("<ul>\n")
+		} else {
+			buf.WriteString("</li>\n")
+			for ; depth > d; depth-- {
+				buf.WriteString("</ul>\n</li>\n")
+			}
+		}
+		depth = d
+		buf.WriteString(fmt.Sprintf(`<li><a href="#%s">%s</a>`, html.EscapeString(entries[i].Anchor), html.EscapeString(entries[i].Text)))
+	}
+	buf.WriteString("</li>\n")
+	for ; depth > 0; depth-- {
+		buf.WriteString("</ul>\n</li>\n")
+	}
+	buf.WriteString("</ul>\n</nav>\n")
+	return buf.String()
+}
@@ -0,0 +1,250 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/chyroc/lark"
+)
+
+// maxCreateBlocks 单次创建子 Block 的数量上限
+const maxCreateBlocks = 50
+
+// ImageReader 读取 Markdown 中引用的图片，返回上传时使用的文件名和图片内容
+type ImageReader func(src string) (string, []byte, error)
+
+// createDocxBlocksReq 创建子 Block 的请求。lark.DocxBlock 的 Divider 字段不是指针，
+// 直接序列化时每个 Block 都会带上 "divider":{}，因此 Children 使用清理后的 JSON
+type createDocxBlocksReq struct {
+	DocumentID string            `path:"document_id" json:"-"`
+	BlockID    string            `path:"block_id" json:"-"`
+	Children   []json.RawMessage `json:"children,omitempty"`
+}
+
+type createDocxBlocksResp struct {
+	Code int64                     `json:"code,omitempty"`
+	Msg  string                    `json:"msg,omitempty"`
+	Data *lark.CreateDocxBlockResp `json:"data,omitempty"`
+}
+
+// createWikiNodeReq lark.CreateWikiNodeReq 中没有标题字段
+type createWikiNodeReq struct {
+	SpaceID         string `path:"space_id" json:"-"`
+	ObjType         string `json:"obj_type,omitempty"`
+	NodeType        string `json:"node_type,omitempty"`
+	ParentNodeToken string `json:"parent_node_token,omitempty"`
+	Title           string `json:"title,omitempty"`
+}
+
+type createWikiNodeResp struct {
+	Code int64                    `json:"code,omitempty"`
+	Msg  string                   `json:"msg,omitempty"`
+	Data *lark.CreateWikiNodeResp `json:"data,omitempty"`
+}
+
+// CreateDocument 在 folderToken 对应的文件夹中新建文档，folderToken 为空时为根目录，返回文档 ID
+func (c *Client) CreateDocument(ctx context.Context, folderToken, title string) (string, error) {
+	req := &lark.CreateDocxReq{Title: &title}
+	if folderToken != "" {
+		req.FolderToken = &folderToken
+	}
+	resp, _, err := c.larkClient.Drive.CreateDocx(ctx, req, c.getMethodOptions()...)
+	if err != nil {
+		return "", err
+	}
+	return resp.Document.DocumentID, nil
+}
+
+// CreateWikiDocument 在知识库节点 parentNodeToken 下新建文档节点，parentNodeToken 为空时为一级节点
+func (c *Client) CreateWikiDocument(ctx context.Context, spaceID, parentNodeToken, title string) (*lark.CreateWikiNodeRespNode, error) {
+	resp := new(createWikiNodeResp)
+	_, err := c.larkClient.RawRequest(ctx, &lark.RawRequestReq{
+		Scope:  "Drive",
+		API:    "CreateWikiNode",
+		Method: "POST",
+		URL:    c.openBaseURL + "/open-apis/wiki/v2/spaces/:space_id/nodes",
+		Body: &createWikiNodeReq{
+			SpaceID:         spaceID,
+			ObjType:         "docx",
+			NodeType:        "origin",
+			ParentNodeToken: parentNodeToken,
+			Title:           title,
+		},
+		MethodOption:          c.getMethodOption(),
+		NeedTenantAccessToken: true,
+		NeedUserAccessToken:   true,
+	}, resp)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Data == nil || resp.Data.Node == nil {
+		return nil, fmt.Errorf("empty wiki node in response")
+	}
+	return resp.Data.Node, nil
+}
+
+// ClearDocument 删除文档的全部内容，title 不为空时同时更新文档标题，用于覆盖已有文档
+func (c *Client) ClearDocument(ctx context.Context, documentID, title string) error {
+	resp, _, err := c.larkClient.Drive.GetDocxBlock(ctx, &lark.GetDocxBlockReq{
+		DocumentID: documentID,
+		BlockID:    documentID,
+	}, c.getMethodOptions()...)
+	if err != nil {
+		return err
+	}
+	if n := len(resp.Block.Children); n > 0 {
+		_, _, err = c.larkClient.Drive.BatchDeleteDocxBlock(ctx, &lark.BatchDeleteDocxBlockReq{
+			DocumentID: documentID,
+			BlockID:    documentID,
+			StartIndex: 0,
+			EndIndex:   int64(n),
+		}, c.getMethodOptions()...)
+		if err != nil {
+			return err
+		}
+	}
+	if title == "" {
+		return nil
+	}
+	_, _, err = c.larkClient.Drive.UpdateDocxBlock(ctx, &lark.UpdateDocxBlockReq{
+		DocumentID: documentID,
+		BlockID:    documentID,
+		UpdateTextElements: &lark.UpdateDocxBlockReqUpdateTextElements{
+			Elements: []*lark.DocxTextElement{textElement(title, lark.DocxTextElementStyle{})},
+		},
+	}, c.getMethodOptions()...)
+	return err
+}
+
+// UploadBlocks 在文档末尾逐层创建 blocks，图片通过 readImage 读取后上传到对应的图片 Block
+func (c *Client) UploadBlocks(ctx context.Context, documentID string, blocks []*UploadBlock, readImage ImageReader) error {
+	return c.createBlocks(ctx, documentID, documentID, blocks, readImage)
+}
+
+func (c *Client) createBlocks(ctx context.Context, documentID, parentID string, blocks []*UploadBlock, readImage ImageReader) error {
+	for start := 0; start < len(blocks); start += maxCreateBlocks {
+		chunk := blocks[start:min(start+maxCreateBlocks, len(blocks))]
+		created, err := c.createDocxBlocks(ctx, documentID, parentID, chunk)
+		if err != nil {
+			return err
+		}
+		for i, b := range chunk {
+			blockID := created[i].BlockID
+			if b.ImageSrc != "" {
+				if err = c.uploadImage(ctx, documentID, blockID, b.ImageSrc, readImage); err != nil {
+					return err
+				}
+			}
+			if b.Cells != nil {
+				if err = c.fillTableCells(ctx, documentID, created[i], b.Cells, readImage); err != nil {
+					return err
+				}
+			}
+			if err = c.createBlocks(ctx, documentID, blockID, b.Children, readImage); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// createDocxBlocks 在 parentID 下追加一批子 Block，返回创建后的 Block
+func (c *Client) createDocxBlocks(ctx context.Context, documentID, parentID string, blocks []*UploadBlock) ([]*lark.DocxBlock, error) {
+	req := &createDocxBlocksReq{DocumentID: documentID, BlockID: parentID}
+	for _, b := range blocks {
+		data, err := marshalCreateBlock(b.Block)
+		if err != nil {
+			return nil, err
+		}
+		req.Children = append(req.Children, data)
+	}
+	resp := new(createDocxBlocksResp)
+	_, err := c.larkClient.RawRequest(ctx, &lark.RawRequestReq{
+		Scope:                 "Drive",
+		API:                   "CreateDocxBlock",
+		Method:                "POST",
+		URL:                   c.openBaseURL + "/open-apis/docx/v1/documents/:document_id/blocks/:block_id/children",
+		Body:                  req,
+		MethodOption:          c.getMethodOption(),
+		NeedTenantAccessToken: true,
+		NeedUserAccessToken:   true,
+	}, resp)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Data == nil || len(resp.Data.Children) != len(blocks) {
+		return nil, fmt.Errorf("unexpected number of blocks created under %s", parentID)
+	}
+	return resp.Data.Children, nil
+}
+
+// marshalCreateBlock 序列化待创建的 Block，去掉非分割线 Block 上多余的 divider 字段
+func marshalCreateBlock(b *lark.DocxBlock) (json.RawMessage, error) {
+	data, err := json.Marshal(b)
+	if err != nil || b.BlockType == lark.DocxBlockTypeDivider {
+		return data, err
+	}
+	fields := map[string]json.RawMessage{}
+	if err = json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	delete(fields, "divider")
+	return json.Marshal(fields)
+}
+
+// fillTableCells 填充表格的单元格。飞书创建表格时会为每个单元格生成一个空的文本 Block，填充后将其删除
+func (c *Client) fillTableCells(ctx context.Context, documentID string, table *lark.DocxBlock, cells [][]*UploadBlock, readImage ImageReader) error {
+	if table.Table == nil || len(table.Table.Cells) < len(cells) {
+		return fmt.Errorf("unexpected number of cells in table %s", table.BlockID)
+	}
+	for i, content := range cells {
+		if len(content) == 0 {
+			continue
+		}
+		cellID := table.Table.Cells[i]
+		if err := c.createBlocks(ctx, documentID, cellID, content, readImage); err != nil {
+			return err
+		}
+		_, _, err := c.larkClient.Drive.BatchDeleteDocxBlock(ctx, &lark.BatchDeleteDocxBlockReq{
+			DocumentID: documentID,
+			BlockID:    cellID,
+			StartIndex: 0,
+			EndIndex:   1,
+		}, c.getMethodOptions()...)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// uploadImage 将图片上传为图片 Block 的素材，再替换到该 Block 上
+func (c *Client) uploadImage(ctx context.Context, documentID, blockID, src string, readImage ImageReader) error {
+	if readImage == nil {
+		return fmt.Errorf("no image reader for %s", src)
+	}
+	name, data, err := readImage(src)
+	if err != nil {
+		return err
+	}
+	extra := fmt.Sprintf(`{"drive_route_token":"%s"}`, documentID)
+	resp, _, err := c.larkClient.Drive.UploadDriveMedia(ctx, &lark.UploadDriveMediaReq{
+		FileName:   name,
+		ParentType: "docx_image",
+		ParentNode: blockID,
+		Size:       int64(len(data)),
+		Extra:      &extra,
+		File:       bytes.NewReader(data),
+	}, c.getMethodOptions()...)
+	if err != nil {
+		return err
+	}
+	_, _, err = c.larkClient.Drive.UpdateDocxBlock(ctx, &lark.UpdateDocxBlockReq{
+		DocumentID:   documentID,
+		BlockID:      blockID,
+		ReplaceImage: &lark.UpdateDocxBlockReqReplaceImage{Token: resp.FileToken},
+	}, c.getMethodOptions()...)
+	return err
+}
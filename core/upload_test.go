@@ -0,0 +1,174 @@
+package core_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Wsine/feishu2md/core"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeDocxServer 模拟飞书开放平台的文档写入接口，记录收到的请求
+type fakeDocxServer struct {
+	*httptest.Server
+	blocks   map[string][]map[string]interface{} // 父 Block 下创建的子 Block
+	deleted  []string                            // 批量删除的父 Block 及区间
+	images   map[string]string                   // 图片 Block 替换后的素材 token
+	uploads  []string                            // 上传的素材文件名
+	wikiNode map[string]interface{}
+	nextID   int
+}
+
+func newFakeDocxServer(t *testing.T) *fakeDocxServer {
+	s := &fakeDocxServer{
+		blocks: map[string][]map[string]interface{}{},
+		images: map[string]string{},
+	}
+	reply := func(w http.ResponseWriter, data interface{}) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"code": 0, "msg": "success", "data": data})
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer u-token", r.Header.Get("Authorization"))
+		path := strings.TrimPrefix(r.URL.Path, "/open-apis")
+		parts := strings.Split(strings.Trim(path, "/"), "/")
+		switch {
+		case r.Method == http.MethodPost && path == "/docx/v1/documents":
+			reply(w, map[string]interface{}{"document": map[string]interface{}{"document_id": "doc"}})
+		case r.Method == http.MethodGet && len(parts) == 6 && parts[4] == "blocks":
+			reply(w, map[string]interface{}{"block": map[string]interface{}{
+				"block_id": parts[5], "block_type": 1, "children": []string{"old1", "old2"},
+			}})
+		case r.Method == http.MethodPost && strings.HasSuffix(path, "/children"):
+			body := struct {
+				Children []map[string]interface{} `json:"children"`
+			}{}
+			json.NewDecoder(r.Body).Decode(&body)
+			parent := parts[5]
+			var created []map[string]interface{}
+			for _, child := range body.Children {
+				s.blocks[parent] = append(s.blocks[parent], child)
+				s.nextID++
+				block := map[string]interface{}{"block_id": fmt.Sprintf("b%d", s.nextID), "block_type": child["block_type"]}
+				if table, ok := child["table"].(map[string]interface{}); ok {
+					property := table["property"].(map[string]interface{})
+					var cells []string
+					for i := 0; i < int(property["row_size"].(float64)*property["column_size"].(float64)); i++ {
+						cells = append(cells, fmt.Sprintf("b%d-cell%d", s.nextID, i))
+					}
+					block["table"] = map[string]interface{}{"cells": cells}
+				}
+				created = append(created, block)
+			}
+			reply(w, map[string]interface{}{"children": created})
+		case r.Method == http.MethodDelete && strings.HasSuffix(path, "/batch_delete"):
+			body := struct {
+				StartIndex int `json:"start_index"`
+				EndIndex   int `json:"end_index"`
+			}{}
+			json.NewDecoder(r.Body).Decode(&body)
+			s.deleted = append(s.deleted, fmt.Sprintf("%s[%d:%d]", parts[5], body.StartIndex, body.EndIndex))
+			reply(w, map[string]interface{}{})
+		case r.Method == http.MethodPatch:
+			body := map[string]map[string]interface{}{}
+			json.NewDecoder(r.Body).Decode(&body)
+			if image, ok := body["replace_image"]; ok {
+				s.images[parts[5]] = image["token"].(string)
+			}
+			reply(w, map[string]interface{}{})
+		case r.Method == http.MethodPost && path == "/drive/v1/medias/upload_all":
+			assert.Equal(t, "docx_image", r.FormValue("parent_type"))
+			file, _, err := r.FormFile("file")
+			assert.NoError(t, err)
+			data, _ := io.ReadAll(file)
+			assert.Equal(t, r.FormValue("size"), fmt.Sprint(len(data)))
+			s.uploads = append(s.uploads, r.FormValue("file_name"))
+			reply(w, map[string]interface{}{"file_token": "box-" + r.FormValue("parent_node")})
+		case r.Method == http.MethodPost && strings.HasSuffix(path, "/nodes"):
+			json.NewDecoder(r.Body).Decode(&s.wikiNode)
+			reply(w, map[string]interface{}{"node": map[string]interface{}{"node_token": "wikinode", "obj_token": "wikidoc"}})
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	return s
+}
+
+func (s *fakeDocxServer) client() *core.Client {
+	return core.NewClient(core.FeishuConfig{
+		AuthType:        core.AuthTypeUser,
+		UserAccessToken: "u-token",
+		OpenBaseURL:     s.URL,
+	})
+}
+
+func TestUploadBlocks(t *testing.T) {
+	server := newFakeDocxServer(t)
+	defer server.Close()
+	client := server.client()
+	ctx := context.Background()
+
+	_, blocks := core.ParseMarkdown("# 标题\n\n" +
+		"- 步骤\n  - 子步骤\n\n" +
+		"![](a.png)\n\n" +
+		"| a | b |\n| --- | --- |\n| 1 | 2 |\n\n" +
+		"---\n")
+	documentID, err := client.CreateDocument(ctx, "folder", "标题")
+	assert.NoError(t, err)
+	assert.Equal(t, "doc", documentID)
+
+	var read []string
+	err = client.UploadBlocks(ctx, documentID, blocks, func(src string) (string, []byte, error) {
+		read = append(read, src)
+		return "a.png", []byte("\x89PNG"), nil
+	})
+	assert.NoError(t, err)
+
+	page := server.blocks["doc"]
+	assert.Len(t, page, 4)
+	// 只有分割线 Block 带有 divider 字段
+	_, ok := page[0]["divider"]
+	assert.False(t, ok)
+	_, ok = page[3]["divider"]
+	assert.True(t, ok)
+
+	// 子 Block 在父 Block 创建后再创建
+	assert.Len(t, server.blocks["b1"], 1)
+
+	// 图片先创建空 Block，上传后替换
+	assert.Equal(t, []string{"a.png"}, read)
+	assert.Equal(t, []string{"a.png"}, server.uploads)
+	assert.Equal(t, "box-b2", server.images["b2"])
+
+	// 表格的单元格填充后删除默认的空文本
+	assert.Len(t, server.blocks["b3-cell0"], 1)
+	assert.Len(t, server.blocks["b3-cell3"], 1)
+	assert.Contains(t, server.deleted, "b3-cell0[0:1]")
+	assert.Len(t, server.deleted, 4)
+}
+
+func TestClearDocument(t *testing.T) {
+	server := newFakeDocxServer(t)
+	defer server.Close()
+
+	assert.NoError(t, server.client().ClearDocument(context.Background(), "doc", ""))
+	assert.Equal(t, []string{"doc[0:2]"}, server.deleted)
+}
+
+func TestCreateWikiDocument(t *testing.T) {
+	server := newFakeDocxServer(t)
+	defer server.Close()
+
+	node, err := server.client().CreateWikiDocument(context.Background(), "space", "parent", "标题")
+	assert.NoError(t, err)
+	assert.Equal(t, "wikidoc", node.ObjToken)
+	assert.Equal(t, "标题", server.wikiNode["title"])
+	assert.Equal(t, "parent", server.wikiNode["parent_node_token"])
+	assert.Equal(t, "docx", server.wikiNode["obj_type"])
+}
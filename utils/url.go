@@ -14,6 +14,12 @@ func UnescapeURL(rawURL string) string {
 	return rawURL
 }
 
+// ExtractBaseURL 提取链接中的站点地址，如 https://sample.feishu.cn
+func ExtractBaseURL(url string) string {
+	reg := regexp.MustCompile(`^https://[\w-.]+`)
+	return reg.FindString(url)
+}
+
 func ValidateDocumentURL(url string) (string, string, error) {
 	reg := regexp.MustCompile("^https://[\\w-.]+/(docs|docx|wiki)/([a-zA-Z0-9]+)")
 	matchResult := reg.FindStringSubmatch(url)
@@ -35,6 +35,22 @@ func TestUnescapeURL(t *testing.T) {
 	}
 }
 
+func TestExtractBaseURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://sample.feishu.cn/docx/doccnByZP6puODElAYySJkPIfUb", "https://sample.feishu.cn"},
+		{"https://sample.sg.larksuite.com/wiki/settings/123", "https://sample.sg.larksuite.com"},
+		{"not a url", ""},
+	}
+	for _, tt := range tests {
+		if got := ExtractBaseURL(tt.url); got != tt.want {
+			t.Errorf("ExtractBaseURL(%v) = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+}
+
 func TestValidateDownloadURL(t *testing.T) {
 	tests := []struct {
 		name  string
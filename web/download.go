@@ -9,7 +9,7 @@ import (
 	"net/http"
 	"net/url"
 	"os"
-	"strings"
+	"path/filepath"
 
 	"github.com/88250/lute"
 	"github.com/Wsine/feishu2md/core"
@@ -24,6 +24,11 @@ func downloadHandler(c *gin.Context) {
 		c.String(http.StatusBadRequest, "Invalid encoded feishu/larksuite URL")
 		return
 	}
+	format := c.DefaultQuery("format", core.FormatMarkdown)
+	if format != core.FormatMarkdown && format != core.FormatHTML {
+		c.String(http.StatusBadRequest, "Unsupported output format")
+		return
+	}
 
 	// Validate the url to download
 	docType, docToken, err := utils.ValidateDocumentURL(feishu_docx_url)
@@ -39,7 +44,10 @@ func downloadHandler(c *gin.Context) {
 
 	// Process the download
 	parser := core.NewParser(config.Output)
-	markdown := ""
+	parser.SetEmbedFetcher(ctx, client)
+	parser.SetUserResolver(client)
+	parser.SetBaseURL(utils.ExtractBaseURL(feishu_docx_url))
+	content := ""
 
 	// for a wiki page, we need to renew docType and docToken first
 	if docType == "wiki" {
@@ -57,31 +65,61 @@ func downloadHandler(c *gin.Context) {
 		return
 	}
 
-	docx, blocks, err := client.GetDocxContent(ctx, docToken)
+	docx, blocks, properties, err := client.GetDocxContent(ctx, docToken)
 	if err != nil {
 		c.String(http.StatusInternalServerError, "Internal error: client.GetDocxContent")
 		log.Panicf("error: %s", err)
 		return
 	}
-	markdown = parser.ParseDocxContent(docx, blocks)
+	parser.SetBlockProperties(properties)
+
+	ext := "md"
+	if format == core.FormatHTML {
+		ext = "html"
+	}
+	mdName := fmt.Sprintf("%s.%s", docToken, ext)
 
+	// Download images and attachments into the zip while parsing,
+	// the document sits at the root of the zip
+	imageDir := filepath.ToSlash(config.Output.DocImageDir("", mdName))
 	zipBuffer := new(bytes.Buffer)
 	writer := zip.NewWriter(zipBuffer)
-	for _, imgToken := range parser.ImgTokens {
-		localLink, rawImage, err := client.DownloadImageRaw(ctx, imgToken, config.Output.ImageDir)
+	zipped := map[string]string{}
+	var assetErr error
+	parser.SetAssetResolver(func(asset *core.Asset) string {
+		key := asset.Kind + "/" + asset.Token
+		if link, ok := zipped[key]; ok {
+			return link
+		}
+		link, err := zipAsset(ctx, client, writer, config.Output, format, imageDir, docx.DocumentID, asset)
 		if err != nil {
-			c.String(http.StatusInternalServerError, "Internal error: client.DownloadImageRaw")
-			log.Panicf("error: %s", err)
-			return
+			if assetErr == nil {
+				assetErr = err
+			}
+			return asset.Token
 		}
-		markdown = strings.Replace(markdown, imgToken, localLink, 1)
-		f, err := writer.Create(localLink)
+		zipped[key] = link
+		return link
+	})
+	if format == core.FormatHTML {
+		content = core.NewHTMLEmitter(config.Output).Emit(parser.BuildDocument(docx, blocks))
+	} else {
+		content = parser.ParseDocxContent(docx, blocks)
+	}
+	if assetErr != nil {
+		c.String(http.StatusInternalServerError, "Internal error: download assets")
+		log.Panicf("error: %s", assetErr)
+		return
+	}
+
+	for _, sidecar := range parser.Sidecars {
+		f, err := writer.Create(sidecar.Name)
 		if err != nil {
 			c.String(http.StatusInternalServerError, "Internal error: zipWriter.Create")
 			log.Panicf("error: %s", err)
 			return
 		}
-		_, err = f.Write(rawImage)
+		_, err = f.Write(sidecar.Content)
 		if err != nil {
 			c.String(http.StatusInternalServerError, "Internal error: zipWriter.Create.Write")
 			log.Panicf("error: %s", err)
@@ -89,14 +127,24 @@ func downloadHandler(c *gin.Context) {
 		}
 	}
 
-	engine := lute.New(func(l *lute.Lute) {
-		l.RenderOptions.AutoSpace = true
-	})
-	result := engine.FormatStr("md", markdown)
+	var result string
+	if format == core.FormatHTML {
+		result, err = core.RenderHTMLPage("", docx.Title, content)
+		if err != nil {
+			c.String(http.StatusInternalServerError, "Internal error: core.RenderHTMLPage")
+			log.Panicf("error: %s", err)
+			return
+		}
+	} else {
+		engine := lute.New(func(l *lute.Lute) {
+			l.RenderOptions.AutoSpace = true
+		})
+		result = engine.FormatStr("md", content)
+	}
 
 	// Set response
-	if len(parser.ImgTokens) > 0 {
-		mdName := fmt.Sprintf("%s.md", docToken)
+	hasImages := format != core.FormatHTML && (len(parser.ImgTokens) > 0 || len(parser.BoardBlocks) > 0)
+	if hasImages || len(parser.FileTokens) > 0 || len(parser.Sidecars) > 0 {
 		f, err := writer.Create(mdName)
 		if err != nil {
 			c.String(http.StatusInternalServerError, "Internal error: zipWriter.Create")
@@ -119,7 +167,44 @@ func downloadHandler(c *gin.Context) {
 		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.zip"`, docToken))
 		c.Data(http.StatusOK, "application/octet-stream", zipBuffer.Bytes())
 	} else {
-		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.md"`, docToken))
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, mdName))
 		c.Data(http.StatusOK, "application/octet-stream", []byte(result))
 	}
 }
+
+// zipAsset 下载图片、画板或附件并写入压缩包，返回文档中的链接。
+// 图片保存在 imageDir 中，HTML 中的图片以 data URI 内嵌，无需打包
+func zipAsset(ctx context.Context, client *core.Client, writer *zip.Writer, output core.OutputConfig, format, imageDir, documentID string, asset *core.Asset) (string, error) {
+	var link string
+	var data []byte
+	var err error
+	switch asset.Kind {
+	case core.AssetImage:
+		link, data, err = client.DownloadImageRaw(ctx, asset.Token, imageDir)
+	case core.AssetBoard:
+		link, data, err = client.DownloadBoardImageRaw(ctx, documentID, asset.Token, imageDir)
+		if err != nil {
+			// 旧版流程图/UML 等无法导出为图片，文档中改为输出指向原文档的链接
+			log.Printf("warning: failed to export board %s: %s", asset.Token, err)
+			return "", nil
+		}
+	case core.AssetFile:
+		link, data, err = client.DownloadAttachmentRaw(ctx, asset.Token, output.AttachmentDir)
+	default:
+		return asset.Token, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if format == core.FormatHTML && asset.Kind != core.AssetFile {
+		return core.DataURI(link, data), nil
+	}
+	f, err := writer.Create(link)
+	if err != nil {
+		return "", err
+	}
+	if _, err = f.Write(data); err != nil {
+		return "", err
+	}
+	return link, nil
+}